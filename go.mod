@@ -5,23 +5,23 @@ go 1.24.5
 require (
 	github.com/alexuserid/go-callvis v0.0.0-20250811162027-cb600e1f78ca
 	github.com/alexuserid/goda v0.0.0-20251005192546-2202b27e5c3a
+	github.com/goccy/go-graphviz v0.1.2
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/mod v0.28.0
 	golang.org/x/text v0.29.0
+	golang.org/x/tools v0.37.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fogleman/gg v1.3.0 // indirect
-	github.com/goccy/go-graphviz v0.1.2 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/google/subcommands v1.2.0 // indirect
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/image v0.31.0 // indirect
-	golang.org/x/mod v0.28.0 // indirect
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
-	golang.org/x/tools v0.37.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/modules"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// apiPackage is a package of the dependency graph.
+type apiPackage struct {
+	// Path is the package import path.
+	Path string `json:"path"`
+	// Dir is the package directory relative to the module root, empty for packages outside the module.
+	Dir        string   `json:"dir,omitempty"`
+	Imports    []string `json:"imports"`
+	ImportedBy []string `json:"importedBy"`
+}
+
+// registerAPI adds json api for scripts and the page.
+func registerAPI(mux *http.ServeMux, page *livePage) {
+	mux.Handle("GET /api/tree", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, page.snapshot().views.packagesTree)
+	}))
+
+	mux.Handle("GET /api/packages", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := page.snapshot().views
+		writeJSON(w, packagesOf(current.deps, current.modulePath))
+	}))
+
+	mux.Handle("GET /api/deps", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, page.snapshot().views.deps)
+	}))
+
+	mux.Handle("GET /api/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, page.snapshot().views.metrics)
+	}))
+
+	mux.Handle("GET /api/modules", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, nonNil(page.snapshot().views.modules))
+	}))
+
+	mux.Handle("GET /api/modules/importers", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		module, ok := findModule(page.snapshot().views.modules, r.URL.Query().Get("path"))
+		if !ok {
+			http.Error(w, "module isn't imported", http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, module.Importers)
+	}))
+
+	mux.Handle("GET /api/cycles", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := page.snapshot().views
+		if r.URL.Query().Get("depth") == "" {
+			writeJSON(w, nonNil(current.cycles))
+			return
+		}
+
+		depth, err := strconv.Atoi(r.URL.Query().Get("depth"))
+		if err != nil || depth < 0 {
+			http.Error(w, "depth must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, nonNil(findCycles(current.testDeps, current.testImports, current.modulePath, depth)))
+	}))
+
+	mux.Handle("GET /api/reachable", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deps := page.snapshot().views.deps
+		from := r.URL.Query().Get("from")
+		if _, ok := deps.Node(from); !ok {
+			http.Error(w, fmt.Sprintf("unknown package '%s'", from), http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, deps.Reachable(from))
+	}))
+
+	mux.Handle("GET /api/dependents", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deps := page.snapshot().views.deps
+		of := r.URL.Query().Get("of")
+		if _, ok := deps.Node(of); !ok {
+			http.Error(w, fmt.Sprintf("unknown package '%s'", of), http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, deps.Dependents(of))
+	}))
+
+	mux.Handle("GET /api/path", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deps := page.snapshot().views.deps
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		for _, id := range []string{from, to} {
+			if _, ok := deps.Node(id); !ok {
+				http.Error(w, fmt.Sprintf("unknown package '%s'", id), http.StatusNotFound)
+				return
+			}
+		}
+
+		// Empty list means the package doesn't import the other one.
+		writeJSON(w, nonNil(deps.ShortestPath(from, to)))
+	}))
+
+	mux.Handle("GET /api/impact", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		summary, err := impactOf(r.Context(), page.snapshot().views, r.URL.Query().Get("base"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, summary)
+	}))
+
+	mux.Handle("GET /api/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultSearchLimit
+		if r.URL.Query().Get("limit") != "" {
+			var err error
+			limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+			if err != nil || limit <= 0 || limit > maxSearchLimit {
+				http.Error(w, fmt.Sprintf("limit must be a number from 1 to %d", maxSearchLimit), http.StatusBadRequest)
+				return
+			}
+		}
+
+		results := page.snapshot().views.search.Search(r.URL.Query().Get("q"), limit)
+		writeJSON(w, nonNil(results))
+	}))
+}
+
+func packagesOf(deps graph.Graph, modulePath string) []apiPackage {
+	packages := make([]apiPackage, 0, len(deps.Nodes))
+	for _, node := range deps.Nodes {
+		packages = append(packages, apiPackage{
+			Path:       node.ID,
+			Dir:        packageDir(node.ID, modulePath),
+			Imports:    nonNil(deps.Imports(node.ID)),
+			ImportedBy: nonNil(deps.ImportedBy(node.ID)),
+		})
+	}
+
+	return packages
+}
+
+// findModule finds imported module by path or id, 'std' is the standard library.
+func findModule(imported []modules.Module, path string) (modules.Module, bool) {
+	for _, module := range imported {
+		if module.Path == path || module.ID() == path {
+			return module, true
+		}
+	}
+
+	return modules.Module{}, false
+}
+
+// packageDir returns package directory relative to the module root.
+func packageDir(pkgPath string, modulePath string) string {
+	if pkgPath == modulePath {
+		return "."
+	}
+
+	rel, ok := strings.CutPrefix(pkgPath, modulePath+"/")
+	if !ok {
+		return ""
+	}
+
+	return rel
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("write json response failed: ", err)
+	}
+}
+
+// nonNil makes empty lists encode as '[]' instead of 'null'.
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+
+	return list
+}
@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/modules"
+	"github.com/alexuserid/go-codevis/internal/backend/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackagesOf(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{
+			{ID: "github.com/username/tmp"},
+			{ID: "github.com/username/tmp/cmd/app"},
+			{ID: "github.com/username/tmp/internal/worker"},
+			{ID: "golang.org/x/text/language"},
+		},
+		Edges: []graph.Edge{
+			{From: "github.com/username/tmp/cmd/app", To: "github.com/username/tmp/internal/worker"},
+			{From: "github.com/username/tmp/cmd/app", To: "github.com/username/tmp"},
+			{From: "github.com/username/tmp/internal/worker", To: "golang.org/x/text/language"},
+		},
+	}
+
+	want := []apiPackage{
+		{
+			Path:       "github.com/username/tmp",
+			Dir:        ".",
+			Imports:    []string{},
+			ImportedBy: []string{"github.com/username/tmp/cmd/app"},
+		},
+		{
+			Path:       "github.com/username/tmp/cmd/app",
+			Dir:        "cmd/app",
+			Imports:    []string{"github.com/username/tmp", "github.com/username/tmp/internal/worker"},
+			ImportedBy: []string{},
+		},
+		{
+			Path:       "github.com/username/tmp/internal/worker",
+			Dir:        "internal/worker",
+			Imports:    []string{"golang.org/x/text/language"},
+			ImportedBy: []string{"github.com/username/tmp/cmd/app"},
+		},
+		{
+			Path:       "golang.org/x/text/language",
+			Imports:    []string{},
+			ImportedBy: []string{"github.com/username/tmp/internal/worker"},
+		},
+	}
+
+	// act
+	got := packagesOf(deps, "github.com/username/tmp")
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestAPITree(t *testing.T) {
+	// arrange
+	packagesTree, err := PackagesTree(testData())
+	assert.NoError(t, err)
+
+	page := newLivePage(snapshot{views: views{packagesTree: packagesTree}})
+	mux := http.NewServeMux()
+	registerAPI(mux, page)
+
+	recorder := httptest.NewRecorder()
+
+	// act
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/tree", nil))
+
+	// assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var got DirNode
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Equal(t, packagesTree, got)
+}
+
+func TestAPISearch(t *testing.T) {
+	// arrange
+	index := search.New([]search.Entry{
+		{Kind: search.KindPackage, Name: "github.com/username/tmp/internal/worker", Package: "github.com/username/tmp/internal/worker"},
+		{Kind: search.KindSymbol, Name: "Pool.Start", Package: "github.com/username/tmp/internal/worker", Path: "internal/worker/pool.go", Line: 10},
+	})
+
+	page := newLivePage(snapshot{views: views{search: index}})
+	mux := http.NewServeMux()
+	registerAPI(mux, page)
+
+	recorder := httptest.NewRecorder()
+
+	// act
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/search?q=start", nil))
+
+	// assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []search.Result
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Len(t, got, 1)
+	assert.Equal(t, "Pool.Start", got[0].Name)
+	assert.Equal(t, 10, got[0].Line)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/search?q=start&limit=1000", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestAPIModuleImporters(t *testing.T) {
+	// arrange
+	imported := []modules.Module{
+		{Path: "golang.org/x/text", Version: "v0.14.0", Importers: map[string][]string{
+			"github.com/username/tmp/internal/worker": {"golang.org/x/text/language"},
+		}},
+		{Path: modules.StdPath, Importers: map[string][]string{"github.com/username/tmp": {"fmt"}}},
+	}
+
+	page := newLivePage(snapshot{views: views{modules: imported}})
+	mux := http.NewServeMux()
+	registerAPI(mux, page)
+
+	recorder := httptest.NewRecorder()
+
+	// act
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/modules/importers?path=golang.org/x/text", nil))
+
+	// assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var got map[string][]string
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Equal(t, imported[0].Importers, got)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/modules/importers?path=std", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/modules/importers?path=example.com/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestAPIReachability(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{{ID: "app"}, {ID: "worker"}, {ID: "log"}},
+		Edges: []graph.Edge{{From: "app", To: "worker"}, {From: "worker", To: "log"}},
+	}
+
+	page := newLivePage(snapshot{views: views{deps: deps}})
+	mux := http.NewServeMux()
+	registerAPI(mux, page)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	// act
+	reachable := get("/api/reachable?from=app")
+	dependents := get("/api/dependents?of=log")
+	path := get("/api/path?from=app&to=log")
+	noPath := get("/api/path?from=log&to=app")
+	unknown := get("/api/reachable?from=unknown")
+
+	// assert
+	assert.JSONEq(t, `["log", "worker"]`, reachable.Body.String())
+	assert.JSONEq(t, `["app", "worker"]`, dependents.Body.String())
+	assert.JSONEq(t, `["app", "worker", "log"]`, path.Body.String())
+	assert.JSONEq(t, `[]`, noPath.Body.String())
+	assert.Equal(t, http.StatusNotFound, unknown.Code)
+}
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/rules"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+var (
+	ErrRulesViolated = errors.New("architecture rules are violated")
+	ErrNoRules       = errors.New("no architecture rules")
+)
+
+// CheckRules prints imports of the module violating architecture rules.
+// It fails when there are violations, so it can be used in CI.
+func CheckRules(cfg Config, w io.Writer) error {
+	ctx := context.Background()
+
+	if err := enterModuleDir(&cfg); err != nil {
+		return err
+	}
+
+	rulesCfg, err := loadRules(cfg.RulesFile)
+	if err != nil {
+		return fmt.Errorf("load rules: %w", err)
+	}
+
+	if len(rulesCfg.Rules) == 0 {
+		return fmt.Errorf("%w: create '%s' or pass '-rules'", ErrNoRules, rules.DefaultFile)
+	}
+
+	modulePath, err := readModulePath(tree.Node{Path: "."})
+	if err != nil {
+		return fmt.Errorf("read module path: %w", err)
+	}
+
+	deps, _, err := loadDepsGraph(ctx, "")
+	if err != nil {
+		return fmt.Errorf("load dependency graph: %w", err)
+	}
+
+	// Empty graph would pass any rules.
+	if len(deps.Nodes) == 0 {
+		return errors.New("package graph is empty")
+	}
+
+	violations := rules.Check(rulesCfg, deps, modulePath)
+	for _, violation := range violations {
+		fmt.Fprintf(w, "%s -> %s: %s\n", violation.From, violation.To, violation.Reason)
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %d imports", ErrRulesViolated, len(violations))
+	}
+
+	fmt.Fprintf(w, "%d rules passed\n", len(rulesCfg.Rules))
+
+	return nil
+}
+
+// loadRules reads rules file. Empty path means rules.DefaultFile, which is optional.
+func loadRules(path string) (rules.Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = rules.DefaultFile
+	}
+
+	rulesCfg, err := rules.LoadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return rules.Config{}, nil
+	}
+
+	if err != nil {
+		return rules.Config{}, fmt.Errorf("load '%s': %w", path, err)
+	}
+
+	return rulesCfg, nil
+}
+
+// highlightViolations returns copy of the graph with violating edges drawn red.
+func highlightViolations(deps graph.Graph, violations []rules.Violation) graph.Graph {
+	reasons := make(map[[2]string][]string, len(violations))
+	for _, violation := range violations {
+		key := [2]string{violation.From, violation.To}
+		reasons[key] = append(reasons[key], violation.Reason)
+	}
+
+	attrs := make(map[[2]string]map[string]string, len(reasons))
+	for key, edgeReasons := range reasons {
+		attrs[key] = map[string]string{
+			"color":    "red",
+			"penwidth": "2",
+			"tooltip":  strings.Join(edgeReasons, "\n"),
+		}
+	}
+
+	return deps.WithEdgeAttrs(attrs)
+}
@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightViolations(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Edges: []graph.Edge{
+			{From: "a", To: "b", Attrs: map[string]string{"color": "#555555"}},
+			{From: "a", To: "c"},
+		},
+	}
+	violations := []rules.Violation{
+		{From: "a", To: "c", Reason: "first"},
+		{From: "a", To: "c", Reason: "second"},
+	}
+
+	// act
+	got := highlightViolations(deps, violations)
+
+	// assert
+	assert.Equal(t, map[string]string{"color": "#555555"}, got.Edges[0].Attrs)
+	assert.Equal(t, map[string]string{"color": "red", "penwidth": "2", "tooltip": "first\nsecond"}, got.Edges[1].Attrs)
+	assert.Nil(t, deps.Edges[1].Attrs, "source graph must not change")
+}
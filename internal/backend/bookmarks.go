@@ -0,0 +1,188 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultBookmarksFile is the bookmarks file in the module root.
+const defaultBookmarksFile = ".codevis-bookmarks.json"
+
+// Bookmark is a named view of the page.
+type Bookmark struct {
+	Name string `json:"name"`
+	// URL is the page path with query and hash keeping the view, e.g. '/?collapse=internal#view=0,0,800,600'.
+	URL string `json:"url"`
+}
+
+// bookmarkStore keeps bookmarks in a json file, the file is created on the first saved bookmark.
+type bookmarkStore struct {
+	mu   sync.Mutex
+	file string
+}
+
+func newBookmarkStore(file string) *bookmarkStore {
+	if file == "" {
+		file = defaultBookmarksFile
+	}
+
+	return &bookmarkStore{file: file}
+}
+
+// List returns bookmarks sorted by name.
+func (s *bookmarkStore) List() ([]Bookmark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+// Save adds the bookmark or replaces one with the same name.
+func (s *bookmarkStore) Save(bookmark Bookmark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bookmarks, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	bookmarks = removeBookmark(bookmarks, bookmark.Name)
+	bookmarks = append(bookmarks, bookmark)
+
+	return s.write(bookmarks)
+}
+
+// Delete removes the bookmark, false is returned when there's no bookmark with the name.
+func (s *bookmarkStore) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bookmarks, err := s.read()
+	if err != nil {
+		return false, err
+	}
+
+	left := removeBookmark(bookmarks, name)
+	if len(left) == len(bookmarks) {
+		return false, nil
+	}
+
+	return true, s.write(left)
+}
+
+func (s *bookmarkStore) read() ([]Bookmark, error) {
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Bookmark{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read bookmarks: %w", err)
+	}
+
+	var bookmarks []Bookmark
+	if err := json.Unmarshal(data, &bookmarks); err != nil {
+		return nil, fmt.Errorf("parse bookmarks '%s': %w", s.file, err)
+	}
+
+	return nonNil(bookmarks), nil
+}
+
+func (s *bookmarkStore) write(bookmarks []Bookmark) error {
+	sort.Slice(bookmarks, func(i, j int) bool {
+		return bookmarks[i].Name < bookmarks[j].Name
+	})
+
+	data, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal bookmarks: %w", err)
+	}
+
+	if err := os.WriteFile(s.file, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write bookmarks: %w", err)
+	}
+
+	return nil
+}
+
+func removeBookmark(bookmarks []Bookmark, name string) []Bookmark {
+	left := make([]Bookmark, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		if bookmark.Name != name {
+			left = append(left, bookmark)
+		}
+	}
+
+	return left
+}
+
+// isPagePath reports whether the url is a path of this page. Bookmarks open views of this page only.
+// Browsers treat backslashes as slashes, so '/\example.com' would lead to another host.
+func isPagePath(rawURL string) bool {
+	if !strings.HasPrefix(rawURL, "/") || strings.Contains(rawURL, "\\") {
+		return false
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return parsed.Scheme == "" && parsed.Host == ""
+}
+
+// registerBookmarks adds api listing, saving and deleting named views of the page.
+func registerBookmarks(mux *http.ServeMux, store *bookmarkStore) {
+	mux.Handle("GET /api/bookmarks", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bookmarks, err := store.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, bookmarks)
+	}))
+
+	mux.Handle("PUT /api/bookmarks", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var bookmark Bookmark
+		if err := json.NewDecoder(r.Body).Decode(&bookmark); err != nil {
+			http.Error(w, "bookmark must be json with name and url", http.StatusBadRequest)
+			return
+		}
+
+		bookmark.Name = strings.TrimSpace(bookmark.Name)
+		if bookmark.Name == "" || !isPagePath(bookmark.URL) {
+			http.Error(w, "bookmark must have name and url path of the page", http.StatusBadRequest)
+			return
+		}
+
+		if err := store.Save(bookmark); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	mux.Handle("DELETE /api/bookmarks", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deleted, err := store.Delete(r.URL.Query().Get("name"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !deleted {
+			http.Error(w, "no such bookmark", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookmarkStore(t *testing.T) {
+	// arrange
+	store := newBookmarkStore(filepath.Join(t.TempDir(), defaultBookmarksFile))
+
+	// act
+	empty, emptyErr := store.List()
+	assert.NoError(t, store.Save(Bookmark{Name: "workers", URL: "/#mark=example.com/app/worker"}))
+	assert.NoError(t, store.Save(Bookmark{Name: "api", URL: "/#view=0,0,100,100"}))
+	assert.NoError(t, store.Save(Bookmark{Name: "workers", URL: "/?collapse=internal"}))
+	deleted, deleteErr := store.Delete("api")
+	missing, missingErr := store.Delete("api")
+	got, err := store.List()
+
+	// assert
+	assert.NoError(t, emptyErr)
+	assert.Empty(t, empty)
+	assert.NoError(t, deleteErr)
+	assert.True(t, deleted)
+	assert.NoError(t, missingErr)
+	assert.False(t, missing)
+	assert.NoError(t, err)
+	assert.Equal(t, []Bookmark{{Name: "workers", URL: "/?collapse=internal"}}, got)
+}
+
+func TestBookmarksAPI(t *testing.T) {
+	// arrange
+	mux := http.NewServeMux()
+	registerBookmarks(mux, newBookmarkStore(filepath.Join(t.TempDir(), defaultBookmarksFile)))
+
+	request := func(method string, target string, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return recorder
+	}
+
+	// act
+	saved := request(http.MethodPut, "/api/bookmarks", `{"name": " review ", "url": "/#view=1,2,3,4"}`)
+	external := request(http.MethodPut, "/api/bookmarks", `{"name": "away", "url": "//example.com"}`)
+	listed := request(http.MethodGet, "/api/bookmarks", "")
+	deleted := request(http.MethodDelete, "/api/bookmarks?name=review", "")
+	missing := request(http.MethodDelete, "/api/bookmarks?name=review", "")
+
+	// assert
+	assert.Equal(t, http.StatusNoContent, saved.Code)
+	assert.Equal(t, http.StatusBadRequest, external.Code)
+	assert.Equal(t, http.StatusOK, listed.Code)
+
+	var got []Bookmark
+	assert.NoError(t, json.Unmarshal(listed.Body.Bytes(), &got))
+	assert.Equal(t, []Bookmark{{Name: "review", URL: "/#view=1,2,3,4"}}, got)
+
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+}
+
+func TestIsPagePath(t *testing.T) {
+	assert.True(t, isPagePath("/#view=1,2,3,4"))
+	assert.True(t, isPagePath("/?collapse=internal&expr=./...:-test"))
+	assert.False(t, isPagePath("//evil.example/"))
+	assert.False(t, isPagePath("/\\evil.example/"))
+	assert.False(t, isPagePath("/\\/evil.example/"))
+	assert.False(t, isPagePath("https://evil.example/"))
+	assert.False(t, isPagePath("javascript:alert(1)"))
+	assert.False(t, isPagePath("/%zz"))
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"runtime"
+)
+
+// localURL returns page url for the listener address.
+// Unspecified hosts (":9798", "0.0.0.0:9798") are replaced with localhost.
+func localURL(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "http://" + addr.String()
+	}
+
+	ip := net.ParseIP(host)
+	if host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start '%s': %w", cmd.Path, err)
+	}
+
+	// Do not leave zombie process.
+	go cmd.Wait()
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package calls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+var ErrBrokenPackages = errors.New("packages have errors")
+
+// Function is a function or a method of the program.
+type Function struct {
+	// Name is the function name relative to its package, e.g. 'Open', '(*File).Read' or 'Open$1' for closures.
+	Name string `json:"name"`
+	// Package is the import path of the declaring package.
+	Package string `json:"package"`
+}
+
+// ID is the package qualified function name.
+func (f Function) ID() string {
+	return f.Package + "." + f.Name
+}
+
+// Call is a call of function To by function From.
+type Call struct {
+	From Function `json:"from"`
+	To   Function `json:"to"`
+}
+
+// Graph is a call graph of a program.
+type Graph struct {
+	Calls []Call `json:"calls"`
+}
+
+// LoadVTA builds call graph of packages matching patterns in the working directory and their dependencies
+// with variable type analysis. The whole program is analyzed, so no main package is needed.
+// Test packages are included with tests.
+func LoadVTA(ctx context.Context, tests bool, patterns ...string) (Graph, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadAllSyntax,
+		Tests:   tests,
+	}
+
+	loaded, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return Graph{}, fmt.Errorf("load packages: %w", err)
+	}
+
+	// SSA of packages with errors is incomplete.
+	var loadErr error
+	packages.Visit(loaded, nil, func(pkg *packages.Package) {
+		if len(pkg.Errors) > 0 && loadErr == nil {
+			loadErr = fmt.Errorf("%w: %s", ErrBrokenPackages, pkg.Errors[0])
+		}
+	})
+	if loadErr != nil {
+		return Graph{}, loadErr
+	}
+
+	prog, _ := ssautil.AllPackages(loaded, ssa.InstantiateGenerics)
+	if err := build(prog); err != nil {
+		return Graph{}, err
+	}
+
+	return vtaGraph(prog), nil
+}
+
+// build builds packages one by one instead of prog.Build, so a package the ssa builder fails on,
+// e.g. using syntax newer than golang.org/x/tools, is reported instead of crashing the server.
+func build(prog *ssa.Program) (err error) {
+	var pkg *ssa.Package
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("build ssa of package '%s': %v", pkg.Pkg.Path(), r)
+		}
+	}()
+
+	for _, pkg = range prog.AllPackages() {
+		pkg.Build()
+	}
+
+	return nil
+}
+
+// vtaGraph refines CHA call graph of the built program with variable type analysis.
+func vtaGraph(prog *ssa.Program) Graph {
+	return fromCallGraph(vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog)))
+}
+
+// fromCallGraph collects calls between functions declared in packages.
+// Synthetic functions like method wrappers aren't in the source, so their calls are skipped.
+func fromCallGraph(cg *callgraph.Graph) Graph {
+	seen := make(map[Call]bool)
+	var g Graph
+	for fn, node := range cg.Nodes {
+		if !isDeclared(fn) {
+			continue
+		}
+
+		for _, edge := range node.Out {
+			if !isDeclared(edge.Callee.Func) {
+				continue
+			}
+
+			call := Call{From: function(fn), To: function(edge.Callee.Func)}
+			if !seen[call] {
+				seen[call] = true
+				g.Calls = append(g.Calls, call)
+			}
+		}
+	}
+
+	sort.Slice(g.Calls, func(i, j int) bool {
+		if g.Calls[i].From != g.Calls[j].From {
+			return g.Calls[i].From.ID() < g.Calls[j].From.ID()
+		}
+		return g.Calls[i].To.ID() < g.Calls[j].To.ID()
+	})
+
+	return g
+}
+
+func isDeclared(fn *ssa.Function) bool {
+	return fn != nil && fn.Synthetic == "" && declaringPackage(fn) != nil
+}
+
+// declaringPackage returns package of the function, generic one for instantiations.
+func declaringPackage(fn *ssa.Function) *ssa.Package {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+
+	return fn.Pkg
+}
+
+func function(fn *ssa.Function) Function {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+
+	return Function{Name: fn.RelString(fn.Pkg.Pkg), Package: fn.Pkg.Pkg.Path()}
+}
+
+// Focused draws calls from and to functions of the package. Functions of other packages are drawn grey
+// and prefixed with the package name.
+func (g Graph) Focused(pkgPath string) graph.Graph {
+	focused := graph.Graph{
+		GraphAttrs: map[string]string{"rankdir": "LR", "label": pkgPath},
+		NodeAttrs:  map[string]string{"shape": "box", "fontname": "Helvetica", "fontsize": "10"},
+	}
+
+	functions := make(map[Function]bool)
+	for _, call := range g.Calls {
+		if call.From.Package != pkgPath && call.To.Package != pkgPath {
+			continue
+		}
+
+		functions[call.From] = true
+		functions[call.To] = true
+		focused.Edges = append(focused.Edges, graph.Edge{From: call.From.ID(), To: call.To.ID()})
+	}
+
+	for fn := range functions {
+		attrs := map[string]string{"label": fn.Name, "tooltip": fn.ID()}
+		if fn.Package != pkgPath {
+			attrs["label"] = path.Base(fn.Package) + "." + fn.Name
+			attrs["shape"] = "ellipse"
+			attrs["color"] = "grey"
+			attrs["fontcolor"] = "grey"
+		}
+		focused.Nodes = append(focused.Nodes, graph.Node{ID: fn.ID(), Attrs: attrs})
+	}
+
+	sort.Slice(focused.Nodes, func(i, j int) bool {
+		return focused.Nodes[i].ID < focused.Nodes[j].ID
+	})
+
+	return focused
+}
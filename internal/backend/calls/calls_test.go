@@ -0,0 +1,96 @@
+package calls
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+const shapeSource = `package shape
+
+type Shape interface{ Area() int }
+
+type Square struct{ side int }
+
+func (s Square) Area() int { return s.side * s.side }
+
+type Circle struct{ r int }
+
+func (c Circle) Area() int { return 3 * c.r * c.r }
+
+func Total(s Shape) int { return s.Area() }
+
+func Run() int { return Total(Square{side: 2}) }
+`
+
+func buildProgram(t *testing.T, pkgPath, source string) *ssa.Program {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "source.go", source, 0)
+	assert.NoError(t, err)
+
+	pkg, _, err := ssautil.BuildPackage(&types.Config{Importer: importer.Default()}, fset,
+		types.NewPackage(pkgPath, "shape"), []*ast.File{file}, ssa.BuilderMode(0))
+	assert.NoError(t, err)
+
+	return pkg.Prog
+}
+
+func TestVTAGraph(t *testing.T) {
+	// arrange
+	prog := buildProgram(t, "example.com/shape", shapeSource)
+
+	fn := func(name string) Function {
+		return Function{Name: name, Package: "example.com/shape"}
+	}
+
+	// act
+	got := vtaGraph(prog)
+
+	// assert
+	assert.Contains(t, got.Calls, Call{From: fn("Run"), To: fn("Total")})
+	assert.Contains(t, got.Calls, Call{From: fn("Total"), To: fn("(Square).Area")})
+	assert.NotContains(t, got.Calls, Call{From: fn("Total"), To: fn("(Circle).Area")},
+		"only squares reach Total")
+}
+
+func TestFocused(t *testing.T) {
+	// arrange
+	app := func(name string) Function { return Function{Name: name, Package: "example.com/app"} }
+	store := func(name string) Function { return Function{Name: name, Package: "example.com/store"} }
+	g := Graph{Calls: []Call{
+		{From: app("Run"), To: store("Open")},
+		{From: store("Open"), To: store("open")},
+		{From: app("main"), To: app("Run")},
+	}}
+
+	// act
+	got := g.Focused("example.com/app")
+
+	// assert
+	assert.Equal(t, []graph.Node{
+		{ID: "example.com/app.Run", Attrs: map[string]string{"label": "Run", "tooltip": "example.com/app.Run"}},
+		{ID: "example.com/app.main", Attrs: map[string]string{"label": "main", "tooltip": "example.com/app.main"}},
+		{ID: "example.com/store.Open", Attrs: map[string]string{
+			"label":     "store.Open",
+			"tooltip":   "example.com/store.Open",
+			"shape":     "ellipse",
+			"color":     "grey",
+			"fontcolor": "grey",
+		}},
+	}, got.Nodes)
+	assert.Equal(t, []graph.Edge{
+		{From: "example.com/app.Run", To: "example.com/store.Open"},
+		{From: "example.com/app.main", To: "example.com/app.Run"},
+	}, got.Edges)
+}
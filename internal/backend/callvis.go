@@ -0,0 +1,388 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	callvis "github.com/alexuserid/go-callvis/origin"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/alexuserid/go-codevis/internal/backend/calls"
+)
+
+var ErrNoMainPackages = errors.New("didn't find main packages")
+
+// Call graph roots chosen with 'root' query parameter.
+const (
+	// rootMain is the main package chosen with 'main' query parameter.
+	rootMain = "main"
+	// rootPackage is the focused package loaded without a main, for libraries. Its graph isn't rooted at
+	// any function, so only static, cha and vta algorithms build it.
+	rootPackage = "package"
+	// rootTests is tests of the module.
+	rootTests = "tests"
+)
+
+// Call graph algorithms chosen with 'algo' query parameter.
+const (
+	AlgoStatic  = "static"
+	AlgoCHA     = "cha"
+	AlgoRTA     = "rta"
+	AlgoVTA     = "vta"
+	AlgoPointer = "pointer"
+)
+
+// callGraphAlgos maps algorithm names to go-callvis types. Vta call graphs are built by codevis itself.
+var callGraphAlgos = map[string]callvis.CallGraphType{
+	AlgoStatic:  callvis.CallGraphTypeStatic,
+	AlgoCHA:     callvis.CallGraphTypeCha,
+	AlgoRTA:     callvis.CallGraphTypeRta,
+	AlgoPointer: callvis.CallGraphTypePointer,
+}
+
+// wholeProgramAlgos start analysis from main functions, so they need main packages or tests.
+var wholeProgramAlgos = []string{AlgoRTA, AlgoPointer}
+
+func knownAlgo(algo string) bool {
+	_, ok := callGraphAlgos[algo]
+	return ok || algo == AlgoVTA
+}
+
+// callvisTarget is what go-callvis adapter loads.
+type callvisTarget struct {
+	pkgPath string
+	tests   bool
+	algo    string
+}
+
+// callvisHandlers serves call graphs of the root chosen with query parameters.
+// Adapter loads the whole program, so adapters are built on the first request and cached.
+type callvisHandlers struct {
+	modulePath string
+	// mainPackages are directories of main packages relative to the module root.
+	mainPackages []string
+	defaultMain  string
+	defaultAlgo  string
+	newHandler   func(target callvisTarget) (http.Handler, error)
+
+	mu       sync.Mutex
+	handlers map[callvisTarget]*callvisEntry
+}
+
+// callvisEntry is a handler of a target built once, requests of other targets don't wait for it.
+type callvisEntry struct {
+	once    sync.Once
+	handler http.Handler
+	err     error
+}
+
+func newCallvisHandlers(modulePath string, mainPackages []string, defaultAlgo string, renderer Renderer) *callvisHandlers {
+	return &callvisHandlers{
+		modulePath:   modulePath,
+		mainPackages: mainPackages,
+		defaultMain:  defaultMainPackage(mainPackages),
+		defaultAlgo:  defaultAlgo,
+		newHandler: func(target callvisTarget) (http.Handler, error) {
+			if target.algo == AlgoVTA {
+				return newVTAHandler(target, modulePath, renderer)
+			}
+
+			return newCallvisAdapter(target)
+		},
+		handlers: make(map[callvisTarget]*callvisEntry),
+	}
+}
+
+func (h *callvisHandlers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target, err := h.target(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handler, err := h.handler(target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	withViewHeader(handler, target).ServeHTTP(w, r)
+}
+
+// defaultRoot is the main package if there is one, libraries get graphs of the focused package.
+func (h *callvisHandlers) defaultRoot() string {
+	if h.defaultMain != "" {
+		return rootMain
+	}
+
+	return rootPackage
+}
+
+// target resolves call graph root of the request.
+func (h *callvisHandlers) target(query url.Values) (callvisTarget, error) {
+	root := query.Get("root")
+	if root == "" {
+		root = h.defaultRoot()
+	}
+
+	algo := query.Get("algo")
+	if algo == "" {
+		algo = h.defaultAlgo
+	}
+
+	if !knownAlgo(algo) {
+		return callvisTarget{}, fmt.Errorf("unknown call graph algorithm '%s'", algo)
+	}
+
+	switch root {
+	case rootMain:
+		mainPkg := query.Get("main")
+		if mainPkg == "" {
+			mainPkg = h.defaultMain
+		}
+
+		if !slices.Contains(h.mainPackages, mainPkg) {
+			return callvisTarget{}, fmt.Errorf("unknown main package '%s'", mainPkg)
+		}
+
+		return callvisTarget{pkgPath: "./" + mainPkg, algo: algo}, nil
+	case rootPackage:
+		dir := packageDir(query.Get("f"), h.modulePath)
+		if dir == "" {
+			return callvisTarget{}, fmt.Errorf("package '%s' is outside the module", query.Get("f"))
+		}
+
+		if slices.Contains(wholeProgramAlgos, algo) {
+			return callvisTarget{}, fmt.Errorf("algorithm '%s' needs main package, root call graph at main package or tests", algo)
+		}
+
+		return callvisTarget{pkgPath: "./" + dir, algo: algo}, nil
+	case rootTests:
+		return callvisTarget{pkgPath: "./...", tests: true, algo: algo}, nil
+	default:
+		return callvisTarget{}, fmt.Errorf("unknown call graph root '%s'", root)
+	}
+}
+
+// handler returns cached handler of the target, building it on the first call.
+// Concurrent calls for the target wait for the same build, failed builds are retried by next calls.
+func (h *callvisHandlers) handler(target callvisTarget) (http.Handler, error) {
+	h.mu.Lock()
+	entry, ok := h.handlers[target]
+	if !ok {
+		entry = &callvisEntry{}
+		h.handlers[target] = entry
+	}
+	h.mu.Unlock()
+
+	// Building loads the whole program and may take minutes, so it runs without the lock.
+	entry.once.Do(func() {
+		entry.handler, entry.err = h.newHandler(target)
+	})
+
+	if entry.err != nil {
+		h.mu.Lock()
+		if h.handlers[target] == entry {
+			delete(h.handlers, target)
+		}
+		h.mu.Unlock()
+
+		return nil, fmt.Errorf("build call graph of '%s': %w", target.pkgPath, entry.err)
+	}
+
+	return entry.handler, nil
+}
+
+// goCallvisHandlers discovers main packages of the module. Handler of the default main package
+// is built right away, so the broken setup is reported on start.
+func goCallvisHandlers(ctx context.Context, modulePath string, defaultAlgo string, renderer Renderer) (*callvisHandlers, error) {
+	if !knownAlgo(defaultAlgo) {
+		return nil, fmt.Errorf("unknown call graph algorithm '%s'", defaultAlgo)
+	}
+
+	mainPackages, err := findMainPackages(ctx, modulePath)
+	if errors.Is(err, ErrNoMainPackages) {
+		log.Println("no main packages, call graphs are unrooted graphs of packages")
+	} else if err != nil {
+		return nil, fmt.Errorf("find main packages: %w", err)
+	}
+
+	handlers := newCallvisHandlers(modulePath, mainPackages, defaultAlgo, renderer)
+	if handlers.defaultMain == "" {
+		return handlers, nil
+	}
+
+	if _, err := handlers.handler(callvisTarget{pkgPath: "./" + handlers.defaultMain, algo: defaultAlgo}); err != nil {
+		return nil, err
+	}
+
+	return handlers, nil
+}
+
+func newCallvisAdapter(target callvisTarget) (http.Handler, error) {
+	callvisCfg := callvis.DefaultConfig()
+	callvisCfg.MainPkgPath = target.pkgPath
+	callvisCfg.Tests = target.tests
+	callvisCfg.CallgraphAlgo = callGraphAlgos[target.algo]
+
+	callvisAdapter, err := callvis.NewGoCodevisAdapter(callvisCfg)
+	if err != nil {
+		return nil, fmt.Errorf("new go-callvis adapter: %w", err)
+	}
+
+	return callvisAdapter.Handler(), nil
+}
+
+// newVTAHandler builds call graph of the target with variable type analysis. It draws calls of the package
+// chosen with 'f' query parameter, the target package by default.
+func newVTAHandler(target callvisTarget, modulePath string, renderer Renderer) (http.Handler, error) {
+	log.Printf("build vta call graph of '%s'", target.pkgPath)
+
+	callGraph, err := calls.LoadVTA(context.Background(), target.tests, target.pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("load vta call graph: %w", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		focus := r.URL.Query().Get("f")
+		if focus == "" {
+			focus = targetPackage(target, modulePath)
+		}
+
+		image, err := renderer.RenderSVG(r.Context(), callGraph.Focused(focus).DOT())
+		if err != nil {
+			log.Println("render vta call graph failed: ", err)
+			http.Error(w, "render vta call graph failed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(image)
+	}), nil
+}
+
+// targetPackage returns import path of the target package, the module root for tests of the module.
+func targetPackage(target callvisTarget, modulePath string) string {
+	dir := strings.TrimPrefix(target.pkgPath, "./")
+	if dir == "." || dir == "..." {
+		return modulePath
+	}
+
+	return modulePath + "/" + dir
+}
+
+// findMainPackages returns sorted directories of module main packages relative to the module root.
+func findMainPackages(ctx context.Context, modulePath string) ([]string, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName,
+	}
+
+	loaded, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	var mainPackages []string
+	for _, pkg := range loaded {
+		dir := packageDir(pkg.PkgPath, modulePath)
+		if pkg.Name == "main" && dir != "" {
+			mainPackages = append(mainPackages, dir)
+		}
+	}
+
+	if len(mainPackages) == 0 {
+		return nil, ErrNoMainPackages
+	}
+
+	sort.Strings(mainPackages)
+
+	return mainPackages, nil
+}
+
+// defaultMainPackage prefers the module root, which is usually the main binary.
+func defaultMainPackage(mainPackages []string) string {
+	if len(mainPackages) == 0 {
+		return ""
+	}
+
+	if slices.Contains(mainPackages, ".") {
+		return "."
+	}
+
+	return mainPackages[0]
+}
+
+// withViewHeader shows call graph root and algorithm above the go-callvis view.
+// Svg image is wrapped into html page to have the header.
+func withViewHeader(handler http.Handler, target callvisTarget) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+
+		body := recorder.Body.Bytes()
+		contentType := recorder.Header().Get("Content-Type")
+		if contentType == "" {
+			contentType = http.DetectContentType(body)
+		}
+
+		// Content sniffing reports svg as xml.
+		isSVG := strings.HasPrefix(contentType, "image/svg+xml") ||
+			(strings.HasPrefix(contentType, "text/xml") && bytes.Contains(body, []byte("<svg")))
+		isHTML := strings.HasPrefix(contentType, "text/html")
+
+		maps.Copy(w.Header(), recorder.Header())
+		if recorder.Code != http.StatusOK || (!isSVG && !isHTML) {
+			w.WriteHeader(recorder.Code)
+			w.Write(body)
+			return
+		}
+
+		root := target.pkgPath
+		if target.tests {
+			root = "tests of " + root
+		}
+
+		header := fmt.Sprintf(`<div class="callvis-header" style="font-family: monospace; padding: 5px;">root: %s, algorithm: %s</div>`,
+			html.EscapeString(root), html.EscapeString(target.algo))
+
+		var page []byte
+		if isSVG {
+			page = fmt.Appendf(nil, "<!DOCTYPE html>\n<html><body>%s%s</body></html>", header, body)
+		} else {
+			page = insertIntoBody(body, header)
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Del("Content-Length")
+		w.Write(page)
+	})
+}
+
+// insertIntoBody inserts content at the beginning of html body, or of the document without body.
+func insertIntoBody(page []byte, content string) []byte {
+	start := bytes.Index(page, []byte("<body"))
+	if start < 0 {
+		return slices.Concat([]byte(content), page)
+	}
+
+	end := bytes.IndexByte(page[start:], '>')
+	if end < 0 {
+		return slices.Concat([]byte(content), page)
+	}
+	end += start + 1
+
+	return slices.Concat(page[:end], []byte(content), page[end:])
+}
@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallvisHandlers(t *testing.T) {
+	// arrange
+	built := map[callvisTarget]int{}
+	handlers := newCallvisHandlers("example.com/m", []string{".", "cmd/worker"}, AlgoCHA, nil)
+	handlers.newHandler = func(target callvisTarget) (http.Handler, error) {
+		built[target]++
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(target.pkgPath))
+		}), nil
+	}
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handlers.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	// act
+	defaultMain := serve("/callvis?f=example.com/m/internal/a")
+	worker := serve("/callvis?root=main&main=cmd/worker")
+	serve("/callvis?main=cmd/worker")
+	library := serve("/callvis?root=package&f=example.com/m/internal/a")
+	tests := serve("/callvis?root=tests")
+	pointer := serve("/callvis?algo=pointer")
+	unknownMain := serve("/callvis?main=cmd/unknown")
+	outside := serve("/callvis?root=package&f=golang.org/x/text")
+	libraryPointer := serve("/callvis?root=package&f=example.com/m/internal/a&algo=pointer")
+	unknownAlgo := serve("/callvis?algo=magic")
+	vta := serve("/callvis?algo=vta")
+
+	// assert
+	assert.Equal(t, "./.", defaultMain.Body.String())
+	assert.Equal(t, "./cmd/worker", worker.Body.String())
+	assert.Equal(t, "./internal/a", library.Body.String())
+	assert.Equal(t, "./...", tests.Body.String())
+	assert.Equal(t, "./.", pointer.Body.String())
+	assert.Equal(t, http.StatusBadRequest, unknownMain.Code)
+	assert.Equal(t, http.StatusBadRequest, outside.Code)
+	assert.Equal(t, http.StatusBadRequest, libraryPointer.Code)
+	assert.Equal(t, http.StatusBadRequest, unknownAlgo.Code)
+	assert.Equal(t, "./.", vta.Body.String())
+	assert.Equal(t, map[callvisTarget]int{
+		{pkgPath: "./.", algo: AlgoCHA}:                1,
+		{pkgPath: "./.", algo: AlgoPointer}:            1,
+		{pkgPath: "./.", algo: AlgoVTA}:                1,
+		{pkgPath: "./cmd/worker", algo: AlgoCHA}:       1,
+		{pkgPath: "./internal/a", algo: AlgoCHA}:       1,
+		{pkgPath: "./...", tests: true, algo: AlgoCHA}: 1,
+	}, built, "handlers must be cached")
+}
+
+func TestCallvisHandlersConcurrentBuilds(t *testing.T) {
+	// arrange
+	building := make(chan struct{})
+	release := make(chan struct{})
+	fails := 1
+	handlers := newCallvisHandlers("example.com/m", []string{".", "cmd/worker"}, AlgoCHA, nil)
+	handlers.newHandler = func(target callvisTarget) (http.Handler, error) {
+		switch target.pkgPath {
+		case "./cmd/worker":
+			close(building)
+			<-release
+		case "./...":
+			if fails > 0 {
+				fails--
+				return nil, errors.New("broken")
+			}
+		}
+		return http.NotFoundHandler(), nil
+	}
+
+	_, err := handlers.handler(callvisTarget{pkgPath: "./.", algo: AlgoCHA})
+	assert.NoError(t, err)
+
+	slow := make(chan error)
+	go func() {
+		_, err := handlers.handler(callvisTarget{pkgPath: "./cmd/worker", algo: AlgoCHA})
+		slow <- err
+	}()
+	<-building
+
+	// act
+	_, cachedErr := handlers.handler(callvisTarget{pkgPath: "./.", algo: AlgoCHA})
+	_, failedErr := handlers.handler(callvisTarget{pkgPath: "./...", tests: true, algo: AlgoCHA})
+	_, retriedErr := handlers.handler(callvisTarget{pkgPath: "./...", tests: true, algo: AlgoCHA})
+	close(release)
+
+	// assert
+	assert.NoError(t, cachedErr, "cached handler is served while another one is built")
+	assert.Error(t, failedErr)
+	assert.NoError(t, retriedErr, "failed build is retried")
+	assert.NoError(t, <-slow)
+}
+
+func TestCallvisHandlersLibrary(t *testing.T) {
+	// arrange
+	handlers := newCallvisHandlers("example.com/m", nil, AlgoCHA, nil)
+
+	// act
+	target, err := handlers.target(map[string][]string{"f": {"example.com/m"}})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, callvisTarget{pkgPath: "./.", algo: AlgoCHA}, target)
+}
+
+func TestTargetPackage(t *testing.T) {
+	assert.Equal(t, "example.com/m", targetPackage(callvisTarget{pkgPath: "./."}, "example.com/m"))
+	assert.Equal(t, "example.com/m/cmd/app", targetPackage(callvisTarget{pkgPath: "./cmd/app"}, "example.com/m"))
+	assert.Equal(t, "example.com/m", targetPackage(callvisTarget{pkgPath: "./...", tests: true}, "example.com/m"))
+}
+
+func TestDefaultMainPackage(t *testing.T) {
+	assert.Equal(t, ".", defaultMainPackage([]string{".", "cmd/app"}))
+	assert.Equal(t, "cmd/app", defaultMainPackage([]string{"cmd/app", "cmd/worker"}))
+	assert.Empty(t, defaultMainPackage(nil))
+}
+
+func TestWithViewHeader(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		"svg": {
+			contentType: "image/svg+xml",
+			body:        "<svg></svg>",
+			want:        "<!DOCTYPE html>\n<html><body>HEADER<svg></svg></body></html>",
+		},
+		"html": {
+			contentType: "text/html",
+			body:        `<html><body class="x"><p>graph</p></body></html>`,
+			want:        `<html><body class="x">HEADER<p>graph</p></body></html>`,
+		},
+		"not a view": {
+			contentType: "application/json",
+			body:        "{}",
+			want:        "{}",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write([]byte(tc.body))
+			})
+			header := `<div class="callvis-header" style="font-family: monospace; padding: 5px;">root: ./cmd/app, algorithm: rta</div>`
+			recorder := httptest.NewRecorder()
+
+			// act
+			withViewHeader(handler, callvisTarget{pkgPath: "./cmd/app", algo: AlgoRTA}).
+				ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/callvis", nil))
+
+			// assert
+			assert.Equal(t, strings.ReplaceAll(tc.want, "HEADER", header), recorder.Body.String())
+		})
+	}
+}
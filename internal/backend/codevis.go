@@ -2,105 +2,389 @@ package backend
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 
-	callvis "github.com/alexuserid/go-callvis/origin"
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/metrics"
+	"github.com/alexuserid/go-codevis/internal/backend/modules"
+	"github.com/alexuserid/go-codevis/internal/backend/rules"
+	"github.com/alexuserid/go-codevis/internal/backend/search"
 	"github.com/alexuserid/go-codevis/internal/backend/tree"
 	"github.com/alexuserid/go-codevis/internal/web"
 	"github.com/alexuserid/goda/pubgraph"
 )
 
-func Run() error {
+// Config holds command line settings of the app.
+type Config struct {
+	// Dir is the root directory of the analyzed module.
+	Dir string
+	// Addr is the address the http server listens on.
+	Addr string
+	// OpenBrowser opens the page in the default browser once the server is up.
+	OpenBrowser bool
+	// WithHidden includes hidden files and directories into the tree.
+	WithHidden bool
+	// Watch rebuilds the page on source changes and reloads it in browsers.
+	Watch bool
+	// Renderer is the graph renderer name, see newRenderer.
+	Renderer string
+	// RulesFile is the architecture rules file. Empty means optional rules.DefaultFile in the module root.
+	RulesFile string
+	// CycleDepth is the number of leading directories packages are grouped by to find import cycles.
+	// Zero means packages aren't grouped.
+	CycleDepth int
+	// CallGraphAlgo is the default call graph algorithm, see AlgoCHA and others.
+	CallGraphAlgo string
+	// Editor opens files from the page, see editorTemplate. Empty means $EDITOR.
+	Editor string
+	// BookmarksFile keeps named views of the page. Empty means defaultBookmarksFile in the module root.
+	BookmarksFile string
+	// Expr is goda package expression the dependency graph is built of, e.g. './...:-test'.
+	// Empty means goda default, packages of the module.
+	Expr string
+	// Collapse are glob patterns of directories relative to the module root, e.g. 'internal/*'.
+	// Packages under matching directories are merged into one node per directory.
+	Collapse []string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Dir:           ".",
+		Addr:          ":9798",
+		Renderer:      RendererAuto,
+		CycleDepth:    2,
+		CallGraphAlgo: AlgoCHA,
+	}
+}
+
+func Run(cfg Config) error {
 	ctx := context.Background()
 
+	currentDirTree, opts, err := prepare(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	settings := pageSettings{LiveReload: cfg.Watch, Structure: true, Source: true, Search: true, Collapse: true, Expressions: true, Reachability: true, Impact: true, Bookmarks: true}
+
+	editor := editorTemplate(cfg.Editor)
+	settings.Editor = editor != ""
+	if err := checkEditor(editor); err != nil {
+		log.Println("files can't be opened in editor: ", err)
+	}
+
+	current, err := buildPage(ctx, currentDirTree, opts, settings)
+	if err != nil {
+		return err
+	}
+
+	page := newLivePage(current)
+
+	mux := http.NewServeMux()
+	mux.Handle("/callvis", page.callvisHandler())
+	mux.Handle("/health", healthHandler(newHealthCache(opts.environment)))
+	registerAPI(mux, page)
+	registerDrilldown(mux, page, opts.renderer)
+	registerSource(mux, page)
+	registerGraphExpression(mux, page)
+	registerBookmarks(mux, newBookmarkStore(opts.bookmarksFile))
+	if editor != "" {
+		registerEditor(mux, page, editor)
+	}
+	mux.Handle("/", page)
+
+	if cfg.Watch {
+		mux.Handle("/events", page.eventsHandler())
+
+		go watch(ctx, cfg.WithHidden, opts.rulesFile, func(dirTree tree.Node) {
+			rebuilt, err := buildPage(ctx, dirTree, opts, settings)
+			if err != nil {
+				log.Println("rebuild page failed: ", err)
+				return
+			}
+
+			page.update(rebuilt)
+		})
+	}
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("listen '%s': %w", cfg.Addr, err)
+	}
+
+	pageURL := localURL(listener.Addr())
+	log.Println("hosting. visit", pageURL)
+
+	if cfg.OpenBrowser {
+		if err := openBrowser(pageURL); err != nil {
+			log.Println("failed to open browser: ", err)
+		}
+	}
+
+	err = http.Serve(listener, mux)
+	if err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return nil
+}
+
+// buildOptions are settings of views building, resolved by prepare.
+type buildOptions struct {
+	renderer      Renderer
+	rulesFile     string
+	cycleDepth    int
+	algo          string
+	expr          string
+	collapse      []string
+	bookmarksFile string
+	environment   EnvironmentReport
+}
+
+// prepare switches to the module directory, checks environment and builds directory tree.
+func prepare(ctx context.Context, cfg Config) (tree.Node, buildOptions, error) {
+	if err := enterModuleDir(&cfg); err != nil {
+		return tree.Node{}, buildOptions{}, err
+	}
+
+	if err := checkCollapsePatterns(cfg.Collapse); err != nil {
+		return tree.Node{}, buildOptions{}, err
+	}
+
 	log.Println("check environment")
-	if err := checkEnvironment(); err != nil {
-		return fmt.Errorf("check environment: %w", err)
+	renderer, environment, err := checkEnvironment(ctx, cfg.Renderer)
+	if err != nil {
+		return tree.Node{}, buildOptions{}, fmt.Errorf("check environment: %w", err)
 	}
+	log.Println("render graphs with", renderer.Name())
 
-	currentDirTree, err := tree.BuildTree(".", false)
+	currentDirTree, err := tree.BuildTree(".", cfg.WithHidden)
 	if err != nil {
-		return fmt.Errorf("build tree: %w", err)
+		return tree.Node{}, buildOptions{}, fmt.Errorf("build tree: %w", err)
 	}
 
-	log.Println("build tree html")
-	treeHTML, err := buildTreeHTML(currentDirTree)
+	opts := buildOptions{
+		renderer:      renderer,
+		rulesFile:     cfg.RulesFile,
+		cycleDepth:    cfg.CycleDepth,
+		algo:          cfg.CallGraphAlgo,
+		expr:          cfg.Expr,
+		collapse:      cfg.Collapse,
+		bookmarksFile: cfg.BookmarksFile,
+		environment:   environment,
+	}
+
+	return currentDirTree, opts, nil
+}
+
+// enterModuleDir makes configured file paths absolute and switches to the module directory.
+// goda and go-callvis resolve packages relative to the working directory.
+func enterModuleDir(cfg *Config) error {
+	if cfg.RulesFile != "" {
+		rulesFile, err := filepath.Abs(cfg.RulesFile)
+		if err != nil {
+			return fmt.Errorf("absolute rules file path: %w", err)
+		}
+		cfg.RulesFile = rulesFile
+	}
+
+	if cfg.BookmarksFile != "" {
+		bookmarksFile, err := filepath.Abs(cfg.BookmarksFile)
+		if err != nil {
+			return fmt.Errorf("absolute bookmarks file path: %w", err)
+		}
+		cfg.BookmarksFile = bookmarksFile
+	}
+
+	if err := os.Chdir(cfg.Dir); err != nil {
+		return fmt.Errorf("change directory to '%s': %w", cfg.Dir, err)
+	}
+
+	return nil
+}
+
+// views are the data the page and api are built from.
+type views struct {
+	// modulePath is the module import path from 'go.mod'.
+	modulePath   string
+	dirTree      tree.Node
+	packagesTree DirNode
+	treeHTML     string
+	deps         graph.Graph
+	decorated    graph.Graph
+	depsSVG      string
+	violations   []rules.Violation
+	cycles       []Cycle
+	metrics      map[string]metrics.Package
+	search       *search.Index
+	// testDeps are imports between module packages with ones of tests, see loadTestDeps.
+	testDeps    graph.Graph
+	testImports map[[2]string]bool
+	// modules are the standard library and external modules the packages import.
+	modules []modules.Module
+	// collapsed are directories merged into aggregate nodes on the rendered graph.
+	collapsed []string
+	// expr is goda package expression the graph is built of.
+	expr string
+}
+
+// buildViews builds directory tree and dependency graph.
+func buildViews(ctx context.Context, currentDirTree tree.Node, opts buildOptions) (views, error) {
+	modulePath, err := readModulePath(currentDirTree)
+	if err != nil {
+		return views{}, fmt.Errorf("read module path: %w", err)
+	}
+
+	packagesTree, err := PackagesTree(currentDirTree)
 	if err != nil {
-		return fmt.Errorf("build tree html: %w", err)
+		return views{}, fmt.Errorf("packages tree: %w", err)
 	}
 
 	log.Println("build deps graph")
-	depsGraph, err := buildDepsGraph(ctx)
+	deps, dot, err := loadDepsGraph(ctx, opts.expr)
 	if err != nil {
-		return fmt.Errorf("build dependency graph: %w", err)
+		return views{}, fmt.Errorf("load dependency graph: %w", err)
 	}
 
-	log.Println("create html")
-	htmlPage, err := composeHTML(treeHTML, depsGraph)
+	log.Println("compute package metrics")
+	pkgMetrics, err := metrics.Load(ctx, deps, "./...")
 	if err != nil {
-		return fmt.Errorf("compose html: %w", err)
+		// Metrics are an overlay, the page is useful without them.
+		log.Println("failed to compute package metrics: ", err)
 	}
 
-	callvisHandler, err := goCallvisHandler(currentDirTree)
+	log.Println("load imported modules")
+	importedModules, err := modules.Load(ctx, "./...")
 	if err != nil {
-		log.Println("do not use go-callvis. failed to get go-callvis handler: ", err)
+		// Module layers are optional as metrics are.
+		log.Println("failed to load imported modules: ", err)
 	}
 
-	if callvisHandler != nil {
-		http.Handle("/callvis", callvisHandler)
+	log.Println("build tree html")
+	treeHTML, err := buildTreeHTML(currentDirTree, metricsColumns(pkgMetrics, modulePath, currentDirTree.AbsPath))
+	if err != nil {
+		return views{}, fmt.Errorf("build tree html: %w", err)
 	}
-	http.Handle("/", http.HandlerFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			w.Write(htmlPage)
-		}))
 
-	log.Println("hosting. visit http://localhost:9798")
-	err = http.ListenAndServe(":9798", nil)
+	rulesCfg, err := loadRules(opts.rulesFile)
 	if err != nil {
-		return fmt.Errorf("listen and serve: %w", err)
+		return views{}, fmt.Errorf("load rules: %w", err)
 	}
 
-	return nil
-}
+	log.Println("load imports of tests")
+	testDeps, testImports, err := loadTestDeps(ctx, modulePath, "./...")
+	if err != nil {
+		// Cycles and impact are still found without imports of tests.
+		log.Println("failed to load imports of tests: ", err)
+		testDeps, testImports = deps, nil
+	}
+
+	violations := rules.Check(rulesCfg, deps, modulePath)
+	cycles := findCycles(testDeps, testImports, modulePath, opts.cycleDepth)
 
-func checkEnvironment() error {
-	cmd := exec.Command("dot")
-	if cmd.Err != nil {
-		return fmt.Errorf("lookup 'dot' util, graphviz: %w", cmd.Err)
+	// Goda output is rendered as is unless the analysis decorates it.
+	decorated := deps
+	if len(cycles) > 0 {
+		log.Printf("found '%d' import cycles", len(cycles))
+		decorated = highlightCycles(decorated, cycles)
 	}
 
-	return nil
+	if len(violations) > 0 {
+		log.Printf("architecture rules violated '%d' times", len(violations))
+		decorated = highlightViolations(decorated, violations)
+	}
+
+	if len(cycles) > 0 || len(violations) > 0 {
+		dot = decorated.DOT()
+	}
+
+	collapsed := collapseDirs(packagesTree, opts.collapse)
+	if len(collapsed) > 0 && len(decorated.Nodes) > 0 {
+		log.Printf("collapse '%d' directories", len(collapsed))
+		dot = collapsedGraph(decorated, modulePath, collapsed).DOT()
+	}
+
+	depsSVG, err := renderDepsGraph(ctx, opts.renderer, dot)
+	if err != nil {
+		return views{}, fmt.Errorf("render dependency graph: %w", err)
+	}
+
+	log.Println("build search index")
+	searchIndex := buildSearchIndex(packagesTree, deps, modulePath)
+
+	return views{
+		modulePath:   modulePath,
+		dirTree:      currentDirTree,
+		packagesTree: packagesTree,
+		treeHTML:     treeHTML,
+		deps:         deps,
+		decorated:    decorated,
+		depsSVG:      depsSVG,
+		violations:   violations,
+		cycles:       cycles,
+		testDeps:     testDeps,
+		testImports:  testImports,
+		metrics:      pkgMetrics,
+		search:       searchIndex,
+		modules:      importedModules,
+		collapsed:    collapsed,
+		expr:         opts.expr,
+	}, nil
 }
 
-func goCallvisHandler(currentDirTree tree.Node) (http.Handler, error) {
-	mainRelativePath := findMainPath(currentDirTree)
-	if mainRelativePath == "" {
-		return nil, fmt.Errorf("didn't find path to main.go")
+// buildPage composes the page and go-callvis handler for it.
+// Missing go-callvis handler isn't an error, the page works without call graphs.
+func buildPage(ctx context.Context, currentDirTree tree.Node, opts buildOptions, settings pageSettings) (snapshot, error) {
+	pageViews, err := buildViews(ctx, currentDirTree, opts)
+	if err != nil {
+		return snapshot{}, err
 	}
 
-	callvisCfg := callvis.DefaultConfig()
-	callvisCfg.MainPkgPath = "./" + mainRelativePath
-	callvisCfg.CallgraphAlgo = callvis.CallGraphTypeCha
+	settings.setViews(pageViews)
 
-	callvisAdapter, err := callvis.NewGoCodevisAdapter(callvisCfg)
+	// Stays nil interface when call graphs aren't available.
+	var callvisHandler http.Handler
+	handlers, err := goCallvisHandlers(ctx, pageViews.modulePath, opts.algo, opts.renderer)
 	if err != nil {
-		return nil, fmt.Errorf("new go-callvis adapter: %w", err)
+		log.Println("do not use go-callvis. failed to get go-callvis handler: ", err)
+	} else {
+		callvisHandler = handlers
+		settings.CallGraph = true
+		settings.MainPackages = handlers.mainPackages
+		settings.DefaultMain = handlers.defaultMain
+		settings.DefaultAlgo = handlers.defaultAlgo
 	}
 
-	return callvisAdapter.Handler(), nil
+	log.Println("create html")
+	htmlPage, err := composeHTML(pageViews.treeHTML, pageViews.depsSVG, settings)
+	if err != nil {
+		return snapshot{}, fmt.Errorf("compose html: %w", err)
+	}
+
+	return snapshot{
+		page:        htmlPage,
+		views:       pageViews,
+		callvis:     callvisHandler,
+		expressions: newExpressionGraphs(opts.renderer),
+		settings:    settings,
+		renderer:    opts.renderer,
+	}, nil
 }
 
 // buildTreeHTML generates directory tree html.
-func buildTreeHTML(dirTree tree.Node) (string, error) {
-	data, err := TreeToHTML(dirTree)
+func buildTreeHTML(dirTree tree.Node, columns map[string]string) (string, error) {
+	data, err := TreeToHTML(dirTree, columns)
 	if err != nil {
 		return "", fmt.Errorf("tree to html: %w", err)
 	}
@@ -108,28 +392,41 @@ func buildTreeHTML(dirTree tree.Node) (string, error) {
 	return string(data), nil
 }
 
-func buildDepsGraph(ctx context.Context) (string, error) {
+// loadDepsGraph returns package graph of goda package expression and goda dot output it's parsed from.
+// Empty expression is goda default, packages of the module.
+// Unparsable output isn't an error, the graph is empty then and only the image is available.
+func loadDepsGraph(ctx context.Context, expr string) (graph.Graph, []byte, error) {
 	log.Println("gather dependencies")
 
 	godaConfig := pubgraph.DefaultConfig()
+	if expr != "" {
+		godaConfig.Args = []string{expr}
+	}
+
 	err := pubgraph.ExecuteGraph(ctx, &godaConfig)
 	if err != nil {
-		return "", fmt.Errorf("execute graph: %w", err)
+		return graph.Graph{}, nil, fmt.Errorf("execute graph: %w", err)
 	}
 
-	log.Println("generate dependency graph")
-	cmdGraphviz := exec.Command("dot")
-	if cmdGraphviz.Err != nil {
-		return "", fmt.Errorf("command graphviz: %w", cmdGraphviz.Err)
+	dot, err := io.ReadAll(godaConfig.Out)
+	if err != nil {
+		return graph.Graph{}, nil, fmt.Errorf("read goda output: %w", err)
 	}
 
-	cmdGraphviz.Args = append(cmdGraphviz.Args, "-T", "svg")
-	cmdGraphviz.Stdin = godaConfig.Out
-	cmdGraphviz.Stderr = os.Stderr
+	deps, err := graph.ParseDOT(dot)
+	if err != nil {
+		log.Println("failed to parse goda output, package graph is empty: ", err)
+	}
 
-	image, err := cmdGraphviz.Output()
+	return deps, dot, nil
+}
+
+// renderDepsGraph renders dot to svg element for the page.
+func renderDepsGraph(ctx context.Context, renderer Renderer, dot []byte) (string, error) {
+	log.Println("generate dependency graph")
+	image, err := renderer.RenderSVG(ctx, dot)
 	if err != nil {
-		return "", fmt.Errorf("graphviz output '%s': %w", string(image), err)
+		return "", fmt.Errorf("render svg: %w", err)
 	}
 
 	log.Println("dependency graph generated")
@@ -146,32 +443,80 @@ func buildDepsGraph(ctx context.Context) (string, error) {
 	return svgHTML, nil
 }
 
-func composeHTML(treeHTML string, graphHTML string) ([]byte, error) {
-	p := message.NewPrinter(language.English)
-
-	p.Printf("compose html. tree size: '%d', graph size: '%d'\n", len(treeHTML), len(graphHTML))
-	rendered := fmt.Sprintf(web.BasicHTML, web.Style, treeHTML, graphHTML, web.JS)
+// pageSettings are passed to the page script as global 'codevisSettings' object.
+type pageSettings struct {
+	// StaticCallvis maps package path to pre-rendered callvis document.
+	// Set in export mode only, the page doesn't request server then.
+	StaticCallvis map[string]staticDocument `json:"staticCallvis,omitempty"`
+	// LiveReload makes the page listen to server updates.
+	LiveReload bool `json:"liveReload,omitempty"`
+	// ModulePath is the module import path.
+	ModulePath string `json:"modulePath"`
+	// Violations are imports breaking architecture rules.
+	Violations []rules.Violation `json:"violations,omitempty"`
+	// Cycles are import cycles between directories.
+	Cycles []Cycle `json:"cycles,omitempty"`
+	// CallGraph is set when the server builds call graphs.
+	CallGraph bool `json:"callGraph,omitempty"`
+	// MainPackages are directories of main packages call graphs may be built for.
+	MainPackages []string `json:"mainPackages,omitempty"`
+	DefaultMain  string   `json:"defaultMain,omitempty"`
+	DefaultAlgo  string   `json:"defaultAlgo,omitempty"`
+	// Metrics are package metrics by package path.
+	Metrics map[string]metrics.Package `json:"metrics,omitempty"`
+	// Diff is set on the page comparing revisions, call graphs aren't available then.
+	Diff *diffSummary `json:"diff,omitempty"`
+	// Structure is set when the server describes packages on double-click.
+	Structure bool `json:"structure,omitempty"`
+	// Source is set when the server serves source files.
+	Source bool `json:"source,omitempty"`
+	// Editor is set when the server opens files in the local editor.
+	Editor bool `json:"editor,omitempty"`
+	// Search is set when the server searches packages, files and symbols.
+	Search bool `json:"search,omitempty"`
+	// Collapse is set when the server renders the graph with collapsed directories.
+	Collapse bool `json:"collapse,omitempty"`
+	// Expressions is set when the server renders graphs of other goda package expressions.
+	Expressions bool `json:"expressions,omitempty"`
+	// Reachability is set when the server finds packages reachable from, depending on or on the path between packages.
+	Reachability bool `json:"reachability,omitempty"`
+	// Impact is set when the server finds packages affected by uncommitted changes.
+	Impact bool `json:"impact,omitempty"`
+	// Bookmarks is set when the server keeps named views of the page.
+	Bookmarks bool `json:"bookmarks,omitempty"`
+	// Expr is goda package expression of the graph, empty for goda default.
+	Expr string `json:"expr,omitempty"`
+	// Collapsed are directories merged into aggregate nodes on this page, relative to the module root.
+	Collapsed []string `json:"collapsed,omitempty"`
+	// Modules are the standard library and external modules imported by the module packages.
+	Modules []modules.Module `json:"modules,omitempty"`
+	// Layers are dependencies outside the module shown on this page, 'std' and 'modules'.
+	Layers []string `json:"layers,omitempty"`
+}
 
-	return []byte(rendered), nil
+// setViews passes analysis results to the page.
+func (s *pageSettings) setViews(v views) {
+	s.ModulePath = v.modulePath
+	s.Violations = v.violations
+	s.Cycles = v.cycles
+	s.Metrics = v.metrics
+	s.Collapsed = v.collapsed
+	s.Modules = v.modules
+	s.Expr = v.expr
 }
 
-func findMainPath(dirTree tree.Node) string {
-	for _, child := range dirTree.Children {
-		if child.Name == "main.go" {
-			// Return parent path of main.go
-			return dirTree.Path
-		}
+func composeHTML(treeHTML string, graphHTML string, settings pageSettings) ([]byte, error) {
+	p := message.NewPrinter(language.English)
 
-		// TODO: fix this workaround
-		// string.Contains test - bad lifehack, but it allows not to go inside testdata packages,
-		// which may contain needless main.go.
-		if child.IsDir && !strings.Contains(child.Name, "test") {
-			p := findMainPath(child)
-			if p != "" {
-				return p
-			}
-		}
+	// json.Marshal escapes '<' and '>', so data can't close the script tag.
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("marshal page settings: %w", err)
 	}
+	script := "const codevisSettings = " + string(settingsJSON) + ";\n" + web.JS
 
-	return ""
+	p.Printf("compose html. tree size: '%d', graph size: '%d'\n", len(treeHTML), len(graphHTML))
+	rendered := fmt.Sprintf(web.BasicHTML, web.Style, treeHTML, graphHTML, script)
+
+	return []byte(rendered), nil
 }
@@ -172,7 +172,7 @@ const expectedTreeHTML = `
 
 // TODO: fix test
 func TestPasteDepsToHTML(t *testing.T) {
-	htmlPage, err := composeHTML(inputTreeHTML, "aaa")
+	htmlPage, err := composeHTML(inputTreeHTML, "aaa", pageSettings{})
 	assert.NoError(t, err)
 
 	want := expectedTreeHTML
@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+// aggregateSuffix ends ids of nodes merging collapsed directories, e.g. 'example.com/app/internal/...'.
+const aggregateSuffix = "/..."
+
+// maxPenWidth limits width of aggregate edges, so heavy ones don't cover the graph.
+const maxPenWidth = 5
+
+// collapseGroup groups module packages under dirs by aggregate node ids.
+// Dirs are slash separated and relative to the module root, '.' is the whole module.
+// Packages under nested dirs are grouped by the outermost one.
+func collapseGroup(modulePath string, dirs []string) func(id string) (string, bool) {
+	prefixes := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		prefix := modulePath
+		if dir = path.Clean(dir); dir != "." {
+			prefix += "/" + dir
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	// Outer directories have shorter paths.
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) < len(prefixes[j])
+	})
+
+	return func(id string) (string, bool) {
+		for _, prefix := range prefixes {
+			if id == prefix || strings.HasPrefix(id, prefix+"/") {
+				return prefix + aggregateSuffix, true
+			}
+		}
+
+		return "", false
+	}
+}
+
+// checkCollapsePatterns validates glob patterns of collapsed directories.
+func checkCollapsePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("collapse pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// collapseDirs returns slash separated directories of the tree matching any of glob patterns.
+// Directories under a matched one aren't matched, they are merged into it anyway.
+func collapseDirs(packagesTree DirNode, patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var dirs []string
+	var walk func(node DirNode)
+	walk = func(node DirNode) {
+		dir := filepath.ToSlash(node.Path)
+		if node.IsRoot {
+			dir = "."
+		}
+
+		for _, pattern := range patterns {
+			// Patterns are checked on start.
+			if matched, _ := path.Match(pattern, dir); matched {
+				dirs = append(dirs, dir)
+				return
+			}
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(packagesTree)
+
+	return dirs
+}
+
+// collapsedGraph merges packages under dirs into aggregate nodes labeled with the number of packages.
+// Edges of aggregate nodes are weighted by the number of imports they merge.
+func collapsedGraph(deps graph.Graph, modulePath string, dirs []string) graph.Graph {
+	group := collapseGroup(modulePath, dirs)
+	idOf := func(id string) string {
+		if grouped, ok := group(id); ok {
+			return grouped
+		}
+		return id
+	}
+
+	packages := make(map[string]int)
+	for _, node := range deps.Nodes {
+		if id, ok := group(node.ID); ok {
+			packages[id]++
+		}
+	}
+
+	imports := make(map[[2]string]int)
+	for _, edge := range deps.Edges {
+		key := [2]string{idOf(edge.From), idOf(edge.To)}
+		if key[0] != key[1] && (key[0] != edge.From || key[1] != edge.To) {
+			imports[key]++
+		}
+	}
+
+	attrs := make(map[string]map[string]string, len(packages))
+	for id, count := range packages {
+		attrs[id] = map[string]string{
+			"label":   fmt.Sprintf(`%s\n%d packages`, id, count),
+			"shape":   "box3d",
+			"tooltip": id,
+		}
+	}
+
+	edgeAttrs := make(map[[2]string]map[string]string, len(imports))
+	for key, count := range imports {
+		edgeAttrs[key] = weightAttrs(count)
+	}
+
+	return deps.Collapsed(group).WithNodeAttrs(attrs).WithEdgeAttrs(edgeAttrs)
+}
+
+// weightAttrs labels an edge merging count imports, heavier edges are thicker and shorter.
+func weightAttrs(count int) map[string]string {
+	return map[string]string{
+		"label":    strconv.Itoa(count),
+		"penwidth": strconv.Itoa(min(count, maxPenWidth)),
+		"weight":   strconv.Itoa(count),
+		"tooltip":  fmt.Sprintf("%d imports", count),
+	}
+}
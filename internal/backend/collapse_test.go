@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+func TestCollapseGroup(t *testing.T) {
+	group := collapseGroup("example.com/app", []string{"internal/worker", "internal", "cmd/"})
+
+	tests := []struct {
+		id    string
+		want  string
+		isSet bool
+	}{
+		{id: "example.com/app/internal", want: "example.com/app/internal/...", isSet: true},
+		{id: "example.com/app/internal/worker/pool", want: "example.com/app/internal/...", isSet: true},
+		{id: "example.com/app/cmd/app", want: "example.com/app/cmd/...", isSet: true},
+		{id: "example.com/app/internalx"},
+		{id: "example.com/app"},
+		{id: "golang.org/x/text"},
+	}
+
+	for _, tt := range tests {
+		got, ok := group(tt.id)
+		assert.Equal(t, tt.isSet, ok, tt.id)
+		assert.Equal(t, tt.want, got, tt.id)
+	}
+
+	whole := collapseGroup("example.com/app", []string{"."})
+	got, ok := whole("example.com/app")
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/app/...", got)
+}
+
+func TestCollapsedGraph(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{{ID: "example.com/app"}, {ID: "example.com/app/internal/a"}, {ID: "example.com/app/internal/b"}},
+		Edges: []graph.Edge{
+			{From: "example.com/app", To: "example.com/app/internal/a"},
+			{From: "example.com/app", To: "example.com/app/internal/b"},
+			{From: "example.com/app/internal/a", To: "example.com/app/internal/b"},
+		},
+	}
+
+	want := graph.Graph{
+		Nodes: []graph.Node{
+			{ID: "example.com/app"},
+			{ID: "example.com/app/internal/...", Attrs: map[string]string{
+				"label":   `example.com/app/internal/...\n2 packages`,
+				"shape":   "box3d",
+				"tooltip": "example.com/app/internal/...",
+			}},
+		},
+		Edges: []graph.Edge{{From: "example.com/app", To: "example.com/app/internal/...", Attrs: map[string]string{
+			"label":    "2",
+			"penwidth": "2",
+			"weight":   "2",
+			"tooltip":  "2 imports",
+		}}},
+	}
+
+	// act
+	got := collapsedGraph(deps, "example.com/app", []string{"internal"})
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestCollapseDirs(t *testing.T) {
+	// arrange
+	input := DirNode{
+		Path:   "/src/app",
+		IsRoot: true,
+		Children: []DirNode{
+			{Path: "cmd", Children: []DirNode{{Path: "cmd/app"}, {Path: "cmd/tool"}}},
+			{Path: "internal", Children: []DirNode{{Path: "internal/worker", Children: []DirNode{{Path: "internal/worker/pool"}}}}},
+		},
+	}
+
+	// act
+	got := collapseDirs(input, []string{"cmd/*", "internal/worker", "internal/*"})
+
+	// assert
+	assert.Equal(t, []string{"cmd/app", "cmd/tool", "internal/worker"}, got)
+	assert.Equal(t, []string{"."}, collapseDirs(input, []string{"."}))
+	assert.Empty(t, collapseDirs(input, nil))
+}
+
+func TestCheckCollapsePatterns(t *testing.T) {
+	assert.NoError(t, checkCollapsePatterns([]string{"internal/*", "cmd/[ab]*"}))
+	assert.Error(t, checkCollapsePatterns([]string{"internal/["}))
+}
+
+func TestCollapsedPage(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{{ID: "example.com/app"}, {ID: "example.com/app/internal/a"}},
+		Edges: []graph.Edge{{From: "example.com/app", To: "example.com/app/internal/a"}},
+	}
+	page := newLivePage(snapshot{
+		page:     []byte("full page"),
+		views:    views{modulePath: "example.com/app", deps: deps, decorated: deps},
+		renderer: echoRenderer{},
+	})
+
+	// act
+	full := httptest.NewRecorder()
+	page.ServeHTTP(full, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	collapsed := httptest.NewRecorder()
+	page.ServeHTTP(collapsed, httptest.NewRequest(http.MethodGet, "/?collapse=internal", nil))
+
+	expanded := httptest.NewRecorder()
+	page.ServeHTTP(expanded, httptest.NewRequest(http.MethodGet, "/?collapse=", nil))
+
+	// assert
+	assert.Equal(t, "full page", full.Body.String())
+	assert.Equal(t, http.StatusOK, collapsed.Code)
+	assert.Contains(t, collapsed.Body.String(), `"example.com/app/internal/..." [`)
+	assert.Contains(t, collapsed.Body.String(), `"collapsed":["internal"]`)
+	assert.Equal(t, http.StatusOK, expanded.Code)
+	assert.NotContains(t, expanded.Body.String(), `"example.com/app/internal/..." [`)
+	assert.Contains(t, expanded.Body.String(), `"example.com/app/internal/a"`)
+}
+
+// echoRenderer returns dot instead of image, so tests see the graph the page is rendered from.
+type echoRenderer struct{}
+
+func (echoRenderer) Name() string {
+	return "echo"
+}
+
+func (echoRenderer) RenderSVG(_ context.Context, dot []byte) ([]byte, error) {
+	return append([]byte("<svg>"), dot...), nil
+}
@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+// Cycle is a group of directories importing each other.
+type Cycle struct {
+	// Groups are directories relative to the module root, or package paths outside the module.
+	Groups []string `json:"groups"`
+	// Imports are package imports between groups of the cycle.
+	Imports []Import `json:"imports"`
+}
+
+// Import is an import of package To by package From.
+type Import struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Test is set for imports of package tests only, test packages can import packages importing them.
+	Test bool `json:"test,omitempty"`
+}
+
+// loadTestDeps loads imports between packages of the module matching patterns, imports of their tests included.
+// Test variants of packages and external test packages are merged into tested ones, e.g. 'p [p.test]' and 'p_test' into 'p'.
+// Imports existing only through tests are returned as test ones.
+func loadTestDeps(ctx context.Context, modulePath string, patterns ...string) (graph.Graph, map[[2]string]bool, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedImports,
+		Tests:   true,
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return graph.Graph{}, nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	deps, testImports := testDepsGraph(pkgs, modulePath)
+
+	return deps, testImports, nil
+}
+
+func testDepsGraph(pkgs []*packages.Package, modulePath string) (graph.Graph, map[[2]string]bool) {
+	// Test variant ids are like 'p [p.test]', ids of imports are enough without loading dependencies.
+	testedPath := func(id string) string {
+		pkgPath, _, _ := strings.Cut(id, " ")
+		return pkgPath
+	}
+
+	nodes := make(map[string]bool)
+	imports := make(map[[2]string]bool)
+	testImports := make(map[[2]string]bool)
+	for _, pkg := range pkgs {
+		// Generated test main packages, e.g. 'p.test'.
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+
+		from := testedPath(pkg.ID)
+		isTest := from != pkg.ID
+		if strings.HasSuffix(pkg.Name, "_test") {
+			from, isTest = strings.TrimSuffix(from, "_test"), true
+		}
+
+		if packageDir(from, modulePath) == "" {
+			continue
+		}
+		nodes[from] = true
+
+		for _, imported := range pkg.Imports {
+			to := testedPath(imported.ID)
+			if to == from || packageDir(to, modulePath) == "" {
+				continue
+			}
+
+			if isTest {
+				testImports[[2]string{from, to}] = true
+			} else {
+				imports[[2]string{from, to}] = true
+			}
+		}
+	}
+
+	var deps graph.Graph
+	for id := range nodes {
+		deps.Nodes = append(deps.Nodes, graph.Node{ID: id})
+	}
+	sort.Slice(deps.Nodes, func(i, j int) bool {
+		return deps.Nodes[i].ID < deps.Nodes[j].ID
+	})
+
+	for key := range testImports {
+		if imports[key] {
+			delete(testImports, key)
+		}
+		imports[key] = true
+	}
+
+	for key := range imports {
+		deps.Edges = append(deps.Edges, graph.Edge{From: key[0], To: key[1]})
+	}
+	sort.Slice(deps.Edges, func(i, j int) bool {
+		if deps.Edges[i].From != deps.Edges[j].From {
+			return deps.Edges[i].From < deps.Edges[j].From
+		}
+		return deps.Edges[i].To < deps.Edges[j].To
+	})
+
+	return deps, testImports
+}
+
+// findCycles returns cycles between module directories cut to the depth.
+// Zero depth means no grouping, every package is a group.
+// Imports of testImports are marked as test ones, cycles through them are near-cycles through tests.
+func findCycles(deps graph.Graph, testImports map[[2]string]bool, modulePath string, depth int) []Cycle {
+	group := func(pkgPath string) string {
+		return packageGroup(pkgPath, modulePath, depth)
+	}
+
+	components := deps.Grouped(group).StronglyConnected()
+
+	componentOf := make(map[string]int)
+	for i, component := range components {
+		for _, id := range component {
+			componentOf[id] = i
+		}
+	}
+
+	cycles := make([]Cycle, len(components))
+	for i, component := range components {
+		cycles[i].Groups = component
+	}
+
+	for _, edge := range deps.Edges {
+		from, to := group(edge.From), group(edge.To)
+		fromComponent, fromOK := componentOf[from]
+		toComponent, toOK := componentOf[to]
+		if !fromOK || !toOK || from == to || fromComponent != toComponent {
+			continue
+		}
+
+		cycles[fromComponent].Imports = append(cycles[fromComponent].Imports, Import{
+			From: edge.From,
+			To:   edge.To,
+			Test: testImports[[2]string{edge.From, edge.To}],
+		})
+	}
+
+	return cycles
+}
+
+// packageGroup returns module directory of the package cut to the depth.
+// Packages outside the module aren't grouped.
+func packageGroup(pkgPath string, modulePath string, depth int) string {
+	dir := packageDir(pkgPath, modulePath)
+	if dir == "" || depth <= 0 {
+		return pkgPath
+	}
+
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// highlightCycles returns copy of the graph with imports between groups of cycles drawn orange.
+// Test imports aren't on the graph, they are added dashed.
+func highlightCycles(deps graph.Graph, cycles []Cycle) graph.Graph {
+	attrs := make(map[[2]string]map[string]string)
+	var testEdges []graph.Edge
+	for _, cycle := range cycles {
+		tooltip := "import cycle: " + strings.Join(cycle.Groups, " ↔ ")
+		for _, imp := range cycle.Imports {
+			edgeAttrs := map[string]string{
+				"color":    "orange",
+				"penwidth": "2",
+				"tooltip":  tooltip,
+			}
+
+			if imp.Test {
+				edgeAttrs["style"] = "dashed"
+				edgeAttrs["tooltip"] = "test " + tooltip
+				testEdges = append(testEdges, graph.Edge{From: imp.From, To: imp.To, Attrs: edgeAttrs})
+				continue
+			}
+
+			attrs[[2]string{imp.From, imp.To}] = edgeAttrs
+		}
+	}
+
+	highlighted := deps.WithEdgeAttrs(attrs)
+	for _, edge := range testEdges {
+		_, fromOK := deps.Node(edge.From)
+		_, toOK := deps.Node(edge.To)
+		// Goda graph of an expression with tests has them already.
+		if fromOK && toOK && !slices.Contains(deps.Imports(edge.From), edge.To) {
+			highlighted.Edges = append(highlighted.Edges, edge)
+		}
+	}
+
+	return highlighted
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestFindCycles(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{
+			{ID: "example.com/m/internal/a/x"},
+			{ID: "example.com/m/internal/a/y"},
+			{ID: "example.com/m/internal/b"},
+			{ID: "example.com/m/cmd/app"},
+			{ID: "golang.org/x/text"},
+		},
+		Edges: []graph.Edge{
+			{From: "example.com/m/cmd/app", To: "example.com/m/internal/a/x"},
+			{From: "example.com/m/internal/a/x", To: "example.com/m/internal/b"},
+			{From: "example.com/m/internal/b", To: "example.com/m/internal/a/y"},
+			{From: "example.com/m/internal/a/y", To: "golang.org/x/text"},
+		},
+	}
+
+	// act
+	byDirectory := findCycles(deps, nil, "example.com/m", 2)
+	byPackage := findCycles(deps, nil, "example.com/m", 0)
+
+	// assert
+	assert.Equal(t, []Cycle{
+		{
+			Groups: []string{"internal/a", "internal/b"},
+			Imports: []Import{
+				{From: "example.com/m/internal/a/x", To: "example.com/m/internal/b"},
+				{From: "example.com/m/internal/b", To: "example.com/m/internal/a/y"},
+			},
+		},
+	}, byDirectory)
+	assert.Empty(t, byPackage)
+}
+
+func TestFindTestCycles(t *testing.T) {
+	// arrange
+	// External test of 'a' imports 'b' importing 'a', e.g. to test 'a' through 'b'.
+	a := &packages.Package{ID: "example.com/m/a", Name: "a", PkgPath: "example.com/m/a"}
+	aVariant := &packages.Package{ID: "example.com/m/a [example.com/m/a.test]", Name: "a", PkgPath: "example.com/m/a"}
+	b := &packages.Package{ID: "example.com/m/b", Name: "b", PkgPath: "example.com/m/b", Imports: map[string]*packages.Package{"example.com/m/a": a}}
+	bVariant := &packages.Package{
+		ID: "example.com/m/b [example.com/m/a.test]", Name: "b", PkgPath: "example.com/m/b",
+		Imports: map[string]*packages.Package{"example.com/m/a": aVariant},
+	}
+	aTest := &packages.Package{
+		ID: "example.com/m/a_test [example.com/m/a.test]", Name: "a_test", PkgPath: "example.com/m/a_test",
+		Imports: map[string]*packages.Package{"example.com/m/a": aVariant, "example.com/m/b": bVariant, "testing": {ID: "testing"}},
+	}
+	testMain := &packages.Package{ID: "example.com/m/a.test", Name: "main", PkgPath: "example.com/m/a.test",
+		Imports: map[string]*packages.Package{"example.com/m/a_test": aTest},
+	}
+
+	// act
+	deps, testImports := testDepsGraph([]*packages.Package{a, b, aVariant, bVariant, aTest, testMain}, "example.com/m")
+	cycles := findCycles(deps, testImports, "example.com/m", 0)
+	highlighted := highlightCycles(graph.Graph{Nodes: deps.Nodes, Edges: deps.Edges[1:]}, cycles)
+
+	// assert
+	assert.Equal(t, []graph.Node{{ID: "example.com/m/a"}, {ID: "example.com/m/b"}}, deps.Nodes)
+	assert.Equal(t, map[[2]string]bool{{"example.com/m/a", "example.com/m/b"}: true}, testImports)
+	assert.Equal(t, []Cycle{{
+		Groups: []string{"example.com/m/a", "example.com/m/b"},
+		Imports: []Import{
+			{From: "example.com/m/a", To: "example.com/m/b", Test: true},
+			{From: "example.com/m/b", To: "example.com/m/a"},
+		},
+	}}, cycles)
+	assert.Len(t, highlighted.Edges, 2)
+	assert.Equal(t, "dashed", highlighted.Edges[1].Attrs["style"], "test import is added dashed")
+}
+
+func TestPackageGroup(t *testing.T) {
+	for _, tc := range []struct {
+		pkgPath string
+		depth   int
+		want    string
+	}{
+		{pkgPath: "example.com/m/internal/a/x", depth: 2, want: "internal/a"},
+		{pkgPath: "example.com/m/internal/a/x", depth: 5, want: "internal/a/x"},
+		{pkgPath: "example.com/m/internal/a/x", depth: 0, want: "example.com/m/internal/a/x"},
+		{pkgPath: "example.com/m", depth: 1, want: "."},
+		{pkgPath: "golang.org/x/text", depth: 1, want: "golang.org/x/text"},
+	} {
+		assert.Equal(t, tc.want, packageGroup(tc.pkgPath, "example.com/m", tc.depth), tc.pkgPath)
+	}
+}
@@ -0,0 +1,385 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+// workingTree is the head label of the diff with uncommitted changes.
+const workingTree = "working tree"
+
+// Colors of changed nodes and edges.
+var (
+	addedNodeAttrs     = map[string]string{"fillcolor": "#c8f0c8", "color": "#2e8b2e"}
+	removedNodeAttrs   = map[string]string{"fillcolor": "#f6c8c8", "color": "#c02828", "style": "rounded,filled,dashed"}
+	unchangedNodeAttrs = map[string]string{"fillcolor": "#f4f4f4", "color": "#bbbbbb", "fontcolor": "#999999"}
+	addedEdgeAttrs     = map[string]string{"color": "#2e8b2e", "penwidth": "2"}
+	removedEdgeAttrs   = map[string]string{"color": "#c02828", "penwidth": "2", "style": "dashed"}
+	unchangedEdgeAttrs = map[string]string{"color": "#cccccc"}
+)
+
+// diffSummary lists package graph changes between revisions.
+type diffSummary struct {
+	Base            string   `json:"base"`
+	Head            string   `json:"head"`
+	AddedPackages   []string `json:"addedPackages"`
+	RemovedPackages []string `json:"removedPackages"`
+	AddedImports    []Import `json:"addedImports"`
+	RemovedImports  []Import `json:"removedImports"`
+}
+
+// checkout is a loaded version of the module.
+type checkout struct {
+	modulePath string
+	dirTree    tree.Node
+	deps       graph.Graph
+}
+
+// Diff writes the page with package graph changes between git revisions of the module
+// and prints the summary. Empty head means the working tree with uncommitted changes.
+func Diff(cfg Config, base string, head string, output string, w io.Writer) error {
+	ctx := context.Background()
+
+	// Resolve before changing working directory.
+	output, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("absolute output path: %w", err)
+	}
+
+	if err := enterModuleDir(&cfg); err != nil {
+		return err
+	}
+
+	log.Println("check environment")
+	renderer, _, err := checkEnvironment(ctx, cfg.Renderer)
+	if err != nil {
+		return fmt.Errorf("check environment: %w", err)
+	}
+
+	moduleDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	// Module may be in a subdirectory of the repository.
+	prefix, err := git(ctx, "rev-parse", "--show-prefix")
+	if err != nil {
+		return fmt.Errorf("find module in repository: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "codevis-diff-")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	log.Println("load revision", base)
+	baseCheckout, err := loadRevision(ctx, base, filepath.Join(tmpDir, "base"), prefix, cfg.WithHidden, cfg.Expr)
+	if err != nil {
+		return fmt.Errorf("load '%s': %w", base, err)
+	}
+
+	var headCheckout checkout
+	if head == "" {
+		head = workingTree
+		log.Println("load", workingTree)
+		headCheckout, err = loadCheckout(ctx, moduleDir, cfg.WithHidden, cfg.Expr)
+	} else {
+		log.Println("load revision", head)
+		headCheckout, err = loadRevision(ctx, head, filepath.Join(tmpDir, "head"), prefix, cfg.WithHidden, cfg.Expr)
+	}
+	if err != nil {
+		return fmt.Errorf("load '%s': %w", head, err)
+	}
+
+	diff := graph.Compare(baseCheckout.deps, headCheckout.deps)
+	summary := summarizeDiff(diff)
+	summary.Base, summary.Head = base, head
+
+	depsSVG, err := renderDepsGraph(ctx, renderer, highlightDiff(diff).DOT())
+	if err != nil {
+		return fmt.Errorf("render dependency graph: %w", err)
+	}
+
+	settings := pageSettings{
+		ModulePath: headCheckout.modulePath,
+		Diff:       &summary,
+	}
+
+	treeHTML, err := diffTreeHTML(baseCheckout.dirTree, headCheckout.dirTree)
+	if err != nil {
+		return fmt.Errorf("build tree html: %w", err)
+	}
+
+	log.Println("create html")
+	htmlPage, err := composeHTML(treeHTML, depsSVG, settings)
+	if err != nil {
+		return fmt.Errorf("compose html: %w", err)
+	}
+
+	if err := os.WriteFile(output, htmlPage, 0o644); err != nil {
+		return fmt.Errorf("write page: %w", err)
+	}
+
+	log.Println("diff written to", output)
+
+	if _, err := summary.WriteTo(w); err != nil {
+		return fmt.Errorf("write summary: %w", err)
+	}
+
+	if cfg.OpenBrowser {
+		if err := openBrowser("file://" + filepath.ToSlash(output)); err != nil {
+			log.Println("failed to open browser: ", err)
+		}
+	}
+
+	return nil
+}
+
+// loadRevision checks out the revision to a temporary worktree and loads the module from it.
+func loadRevision(ctx context.Context, rev string, worktree string, prefix string, withHidden bool, expr string) (checkout, error) {
+	if _, err := git(ctx, "worktree", "add", "--detach", worktree, rev); err != nil {
+		return checkout{}, fmt.Errorf("add worktree: %w", err)
+	}
+
+	defer func() {
+		if _, err := git(ctx, "worktree", "remove", "--force", worktree); err != nil {
+			log.Printf("failed to remove worktree '%s': %s", worktree, err)
+		}
+	}()
+
+	return loadCheckout(ctx, filepath.Join(worktree, prefix), withHidden, expr)
+}
+
+// loadCheckout loads tree and package graph of goda package expression of the module directory.
+// Empty expression is goda default, packages of the module.
+func loadCheckout(ctx context.Context, dir string, withHidden bool, expr string) (checkout, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return checkout{}, fmt.Errorf("get working directory: %w", err)
+	}
+
+	// goda loads packages of the working directory.
+	if err := os.Chdir(dir); err != nil {
+		return checkout{}, fmt.Errorf("change directory to '%s': %w", dir, err)
+	}
+	defer os.Chdir(wd)
+
+	dirTree, err := tree.BuildTree(".", withHidden)
+	if err != nil {
+		return checkout{}, fmt.Errorf("build tree: %w", err)
+	}
+
+	modulePath, err := readModulePath(dirTree)
+	if err != nil {
+		return checkout{}, fmt.Errorf("read module path: %w", err)
+	}
+
+	deps, _, err := loadDepsGraph(ctx, expr)
+	if err != nil {
+		return checkout{}, fmt.Errorf("load dependency graph: %w", err)
+	}
+
+	// Empty graph would show everything as added or removed.
+	if len(deps.Nodes) == 0 {
+		return checkout{}, errors.New("package graph is empty")
+	}
+
+	return checkout{
+		modulePath: modulePath,
+		dirTree:    dirTree,
+		deps:       deps,
+	}, nil
+}
+
+// diffTreeHTML renders head tree with entries removed since base, added and removed entries are marked.
+func diffTreeHTML(base, head tree.Node) (string, error) {
+	changes := make(map[string]graph.Change)
+	packagesTree, err := PackagesTree(mergeTrees(base, head, changes))
+	if err != nil {
+		return "", fmt.Errorf("packages tree: %w", err)
+	}
+
+	htmlNode := toHTMLNode(packagesTree, nil)
+	markTreeChanges(&htmlNode, changes)
+
+	data, err := htmlTree(htmlNode)
+	if err != nil {
+		return "", fmt.Errorf("build html tree: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// mergeTrees adds entries of base missing in head to head and records added and removed entries by path.
+// Both trees are built from the module root, so entries of the same file have the same relative path.
+func mergeTrees(base, head tree.Node, changes map[string]graph.Change) tree.Node {
+	baseChildren := make(map[string]tree.Node, len(base.Children))
+	for _, child := range base.Children {
+		baseChildren[child.Name] = child
+	}
+
+	merged := head
+	merged.Children = nil
+	for _, child := range head.Children {
+		baseChild, ok := baseChildren[child.Name]
+		delete(baseChildren, child.Name)
+
+		switch {
+		case !ok:
+			markTree(child, graph.Added, changes)
+		case child.IsDir && baseChild.IsDir:
+			child = mergeTrees(baseChild, child, changes)
+		case child.IsDir != baseChild.IsDir:
+			// Replaced file and directory are shown as the head one.
+			markTree(child, graph.Added, changes)
+		}
+		merged.Children = append(merged.Children, child)
+	}
+
+	for _, child := range baseChildren {
+		markTree(child, graph.Removed, changes)
+		merged.Children = append(merged.Children, child)
+	}
+
+	sort.Slice(merged.Children, func(i, j int) bool {
+		return merged.Children[i].Name < merged.Children[j].Name
+	})
+
+	return merged
+}
+
+// markTree records the change of the entry and all entries under it.
+func markTree(node tree.Node, change graph.Change, changes map[string]graph.Change) {
+	changes[node.Path] = change
+	for _, child := range node.Children {
+		markTree(child, change, changes)
+	}
+}
+
+// markTreeChanges adds 'tree-added' and 'tree-removed' classes to changed entries.
+func markTreeChanges(node *HTMLNode, changes map[string]graph.Change) {
+	switch changes[node.ID] {
+	case graph.Added:
+		node.Class += " tree-added"
+	case graph.Removed:
+		node.Class += " tree-removed"
+	}
+
+	for i := range node.Children {
+		markTreeChanges(&node.Children[i], changes)
+	}
+}
+
+// highlightDiff colors added nodes and edges green, removed red and greys unchanged ones.
+func highlightDiff(diff graph.Diff) graph.Graph {
+	changeAttrs := func(change graph.Change, added, removed, unchanged map[string]string) map[string]string {
+		switch change {
+		case graph.Added:
+			return added
+		case graph.Removed:
+			return removed
+		default:
+			return unchanged
+		}
+	}
+
+	nodeAttrs := make(map[string]map[string]string, len(diff.Nodes))
+	for id, change := range diff.Nodes {
+		nodeAttrs[id] = changeAttrs(change, addedNodeAttrs, removedNodeAttrs, unchangedNodeAttrs)
+	}
+
+	edgeAttrs := make(map[[2]string]map[string]string, len(diff.Edges))
+	for key, change := range diff.Edges {
+		edgeAttrs[key] = changeAttrs(change, addedEdgeAttrs, removedEdgeAttrs, unchangedEdgeAttrs)
+	}
+
+	return diff.Merged.WithNodeAttrs(nodeAttrs).WithEdgeAttrs(edgeAttrs)
+}
+
+func summarizeDiff(diff graph.Diff) diffSummary {
+	summary := diffSummary{
+		AddedPackages:   []string{},
+		RemovedPackages: []string{},
+		AddedImports:    []Import{},
+		RemovedImports:  []Import{},
+	}
+
+	for id, change := range diff.Nodes {
+		switch change {
+		case graph.Added:
+			summary.AddedPackages = append(summary.AddedPackages, id)
+		case graph.Removed:
+			summary.RemovedPackages = append(summary.RemovedPackages, id)
+		}
+	}
+
+	for key, change := range diff.Edges {
+		switch change {
+		case graph.Added:
+			summary.AddedImports = append(summary.AddedImports, Import{From: key[0], To: key[1]})
+		case graph.Removed:
+			summary.RemovedImports = append(summary.RemovedImports, Import{From: key[0], To: key[1]})
+		}
+	}
+
+	sort.Strings(summary.AddedPackages)
+	sort.Strings(summary.RemovedPackages)
+	sortImports(summary.AddedImports)
+	sortImports(summary.RemovedImports)
+
+	return summary
+}
+
+func (s diffSummary) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "%s..%s\n", s.Base, s.Head)
+	fmt.Fprintf(buf, "packages: +%d -%d, imports: +%d -%d\n",
+		len(s.AddedPackages), len(s.RemovedPackages), len(s.AddedImports), len(s.RemovedImports))
+
+	for _, pkg := range s.AddedPackages {
+		fmt.Fprintf(buf, "+ %s\n", pkg)
+	}
+	for _, pkg := range s.RemovedPackages {
+		fmt.Fprintf(buf, "- %s\n", pkg)
+	}
+	for _, imp := range s.AddedImports {
+		fmt.Fprintf(buf, "+ %s -> %s\n", imp.From, imp.To)
+	}
+	for _, imp := range s.RemovedImports {
+		fmt.Fprintf(buf, "- %s -> %s\n", imp.From, imp.To)
+	}
+
+	return buf.WriteTo(w)
+}
+
+func sortImports(imports []Import) {
+	sort.Slice(imports, func(i, j int) bool {
+		if imports[i].From != imports[j].From {
+			return imports[i].From < imports[j].From
+		}
+		return imports[i].To < imports[j].To
+	})
+}
+
+// git runs git command in the working directory and returns its trimmed output.
+func git(ctx context.Context, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeDiff(t *testing.T) {
+	// arrange
+	diff := graph.Compare(
+		graph.Graph{
+			Nodes: []graph.Node{{ID: "a"}, {ID: "b"}, {ID: "old"}},
+			Edges: []graph.Edge{{From: "a", To: "b"}, {From: "a", To: "old"}},
+		},
+		graph.Graph{
+			Nodes: []graph.Node{{ID: "a"}, {ID: "b"}, {ID: "new"}},
+			Edges: []graph.Edge{{From: "a", To: "b"}, {From: "b", To: "new"}, {From: "a", To: "new"}},
+		},
+	)
+
+	// act
+	got := summarizeDiff(diff)
+
+	// assert
+	assert.Equal(t, diffSummary{
+		AddedPackages:   []string{"new"},
+		RemovedPackages: []string{"old"},
+		AddedImports:    []Import{{From: "a", To: "new"}, {From: "b", To: "new"}},
+		RemovedImports:  []Import{{From: "a", To: "old"}},
+	}, got)
+}
+
+func TestHighlightDiff(t *testing.T) {
+	// arrange
+	diff := graph.Compare(
+		graph.Graph{Nodes: []graph.Node{{ID: "a"}, {ID: "old"}}},
+		graph.Graph{Nodes: []graph.Node{{ID: "a", Attrs: map[string]string{"label": "a"}}, {ID: "new"}}},
+	)
+
+	// act
+	got := highlightDiff(diff)
+
+	// assert
+	assert.Equal(t, "a", got.Nodes[0].Attrs["label"])
+	assert.Equal(t, unchangedNodeAttrs["fillcolor"], got.Nodes[0].Attrs["fillcolor"])
+	assert.Equal(t, addedNodeAttrs, got.Nodes[1].Attrs)
+	assert.Equal(t, removedNodeAttrs, got.Nodes[2].Attrs)
+}
+
+func TestMergeTrees(t *testing.T) {
+	// arrange
+	file := func(path string) tree.Node {
+		return tree.Node{Name: filepath.Base(path), Path: path}
+	}
+	base := tree.Node{Name: "app", Path: ".", IsDir: true, Children: []tree.Node{
+		{Name: "api", Path: "api", IsDir: true, Children: []tree.Node{file("api/api.go"), file("api/old.go")}},
+		{Name: "legacy", Path: "legacy", IsDir: true, Children: []tree.Node{file("legacy/legacy.go")}},
+		file("go.mod"),
+	}}
+	head := tree.Node{Name: "app", Path: ".", IsDir: true, Children: []tree.Node{
+		{Name: "api", Path: "api", IsDir: true, Children: []tree.Node{file("api/api.go"), file("api/new.go")}},
+		file("go.mod"),
+	}}
+	changes := make(map[string]graph.Change)
+
+	// act
+	got := mergeTrees(base, head, changes)
+
+	// assert
+	assert.Equal(t, tree.Node{Name: "app", Path: ".", IsDir: true, Children: []tree.Node{
+		{Name: "api", Path: "api", IsDir: true, Children: []tree.Node{file("api/api.go"), file("api/new.go"), file("api/old.go")}},
+		file("go.mod"),
+		{Name: "legacy", Path: "legacy", IsDir: true, Children: []tree.Node{file("legacy/legacy.go")}},
+	}}, got)
+	assert.Equal(t, map[string]graph.Change{
+		"api/new.go":       graph.Added,
+		"api/old.go":       graph.Removed,
+		"legacy":           graph.Removed,
+		"legacy/legacy.go": graph.Removed,
+	}, changes)
+}
+
+func TestMarkTreeChanges(t *testing.T) {
+	// arrange
+	node := HTMLNode{ID: "/src/app", Class: "root", Children: []HTMLNode{
+		{ID: "api", Class: "gopkg", Children: []HTMLNode{{ID: "api/new.go", Class: "gofile"}}},
+		{ID: "legacy", Class: "gopkg"},
+	}}
+
+	// act
+	markTreeChanges(&node, map[string]graph.Change{"api/new.go": graph.Added, "legacy": graph.Removed})
+
+	// assert
+	assert.Equal(t, HTMLNode{ID: "/src/app", Class: "root", Children: []HTMLNode{
+		{ID: "api", Class: "gopkg", Children: []HTMLNode{{ID: "api/new.go", Class: "gofile tree-added"}}},
+		{ID: "legacy", Class: "gopkg tree-removed"},
+	}}, node)
+}
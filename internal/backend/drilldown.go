@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/structure"
+)
+
+// registerDrilldown adds types and functions of a package as json and as rendered graph.
+func registerDrilldown(mux *http.ServeMux, page *livePage, renderer Renderer) {
+	mux.Handle("GET /api/structure", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkg, err := loadStructure(r.Context(), page.snapshot().views.deps, r.URL.Query().Get("path"))
+		if err != nil {
+			writeStructureError(w, err)
+			return
+		}
+
+		writeJSON(w, pkg)
+	}))
+
+	mux.Handle("GET /structure", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkg, err := loadStructure(r.Context(), page.snapshot().views.deps, r.URL.Query().Get("path"))
+		if err != nil {
+			writeStructureError(w, err)
+			return
+		}
+
+		image, err := renderer.RenderSVG(r.Context(), structureGraph(pkg).DOT())
+		if err != nil {
+			log.Println("render package structure failed: ", err)
+			http.Error(w, "render package structure failed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(image)
+	}))
+}
+
+// loadStructure describes a package of the dependency graph with calls from its importers.
+func loadStructure(ctx context.Context, deps graph.Graph, pkgPath string) (structure.Package, error) {
+	if _, ok := deps.Node(pkgPath); !ok {
+		return structure.Package{}, fmt.Errorf("%w: '%s'", structure.ErrUnknownPackage, pkgPath)
+	}
+
+	log.Printf("load structure of '%s'", pkgPath)
+	pkg, err := structure.Load(ctx, pkgPath, deps.ImportedBy(pkgPath)...)
+	if err != nil {
+		return structure.Package{}, fmt.Errorf("load package structure: %w", err)
+	}
+
+	return pkg, nil
+}
+
+// structureGraph links calling packages to their structure, so the panel drills down further.
+func structureGraph(pkg structure.Package) graph.Graph {
+	links := make(map[string]map[string]string)
+	for _, function := range pkg.Functions {
+		for _, caller := range function.CalledFrom {
+			links[caller] = map[string]string{"URL": structureURL(caller)}
+		}
+	}
+
+	return pkg.Graph().WithNodeAttrs(links)
+}
+
+func structureURL(pkgPath string) string {
+	return "/structure?" + url.Values{"path": {pkgPath}}.Encode()
+}
+
+func writeStructureError(w http.ResponseWriter, err error) {
+	if errors.Is(err, structure.ErrUnknownPackage) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	log.Println(err)
+	http.Error(w, "load package structure failed", http.StatusInternalServerError)
+}
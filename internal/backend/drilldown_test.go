@@ -0,0 +1,36 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/structure"
+)
+
+func TestStructureGraph(t *testing.T) {
+	// arrange
+	pkg := structure.Package{
+		Path: "github.com/username/tmp/internal/worker",
+		Functions: []structure.Function{
+			{Name: "Run", Exported: true, CalledFrom: []string{"github.com/username/tmp/cmd/app"}},
+		},
+	}
+
+	// act
+	g := structureGraph(pkg)
+
+	// assert
+	caller, ok := g.Node("github.com/username/tmp/cmd/app")
+	assert.True(t, ok)
+	assert.Equal(t, "/structure?path=github.com%2Fusername%2Ftmp%2Fcmd%2Fapp", caller.Attrs["URL"])
+}
+
+func TestLoadStructureUnknownPackage(t *testing.T) {
+	// act
+	_, err := loadStructure(t.Context(), graph.Graph{}, "github.com/username/tmp")
+
+	// assert
+	assert.ErrorIs(t, err, structure.ErrUnknownPackage)
+}
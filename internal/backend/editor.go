@@ -0,0 +1,250 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+// ErrTerminalEditor is returned for editors needing a terminal, the server has none to run them in.
+var ErrTerminalEditor = errors.New("editor runs in a terminal")
+
+// terminalEditors are commands of editors running in a terminal.
+var terminalEditors = []string{"vi", "vim", "nvim", "vim.basic", "nano", "pico", "micro", "hx", "helix", "kak", "joe", "ne", "mg", "ed"}
+
+// editorPresets are templates of known editors by name.
+var editorPresets = map[string]string{
+	"code":   "code -g {file}:{line}",
+	"goland": "goland --line {line} {file}",
+	"vscode": "vscode://file/{file}:{line}",
+}
+
+// editorTarget is what the page asks to open: a file or directory of the tree, a package or a function of it.
+type editorTarget struct {
+	Path string `json:"path,omitempty"`
+	Pkg  string `json:"pkg,omitempty"`
+	Func string `json:"func,omitempty"`
+}
+
+// editorResponse has URI the browser opens, empty when the server launched the editor itself.
+type editorResponse struct {
+	URI string `json:"uri,omitempty"`
+}
+
+// editorTemplate resolves editor setting: a preset name or a template with '{file}' and '{line}' placeholders.
+// Empty setting means $EDITOR. Templates without '{file}' get the file as the last argument.
+func editorTemplate(editor string) string {
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if preset, ok := editorPresets[editor]; ok {
+		return preset
+	}
+
+	if editor != "" && !strings.Contains(editor, "{file}") {
+		editor += " {file}"
+	}
+
+	return editor
+}
+
+// registerEditor adds api opening module files in the local editor.
+// Json body makes browsers send preflight request, so other sites can't open files.
+func registerEditor(mux *http.ServeMux, page *livePage, template string) {
+	mux.Handle("POST /api/open", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Server may listen on all interfaces, processes are launched for the page on this machine only.
+		if err := checkLocalRequest(r); err != nil {
+			log.Println("refused to open editor: ", err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			http.Error(w, "expected json body", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var target editorTarget
+		if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+
+		current := page.snapshot().views
+		file, line, err := resolveEditorTarget(current.dirTree, current.modulePath, target)
+		if err != nil {
+			writeSourceError(w, err)
+			return
+		}
+
+		uri, err := openInEditor(template, file, line)
+		if errors.Is(err, ErrTerminalEditor) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			log.Println("failed to open editor: ", err)
+			http.Error(w, "failed to open editor", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, editorResponse{URI: uri})
+	}))
+}
+
+// checkEditor fails for terminal editors, e.g. 'vim' from $EDITOR.
+func checkEditor(template string) error {
+	args := strings.Fields(template)
+	if len(args) == 0 || strings.Contains(template, "://") {
+		return nil
+	}
+
+	if name := filepath.Base(args[0]); slices.Contains(terminalEditors, name) {
+		return fmt.Errorf("%w: '%s', set '-editor' to a GUI one, e.g. 'code'", ErrTerminalEditor, name)
+	}
+
+	return nil
+}
+
+// checkLocalRequest fails for requests from other hosts and for pages of other sites.
+// Host is checked as well, since pages of other sites may resolve their names to loopback.
+func checkLocalRequest(r *http.Request) error {
+	remoteHost, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || !isLoopback(remoteHost) {
+		return fmt.Errorf("request from '%s' isn't from this machine", r.RemoteAddr)
+	}
+
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(r.Host); err == nil {
+		host = hostname
+	}
+	if !isLoopback(host) {
+		return fmt.Errorf("host '%s' isn't loopback", r.Host)
+	}
+
+	if origin := r.Header.Get("Origin"); origin != "" {
+		originURL, err := url.Parse(origin)
+		if err != nil || originURL.Host != r.Host {
+			return fmt.Errorf("origin '%s' isn't the page", origin)
+		}
+	}
+
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// resolveEditorTarget returns absolute path of go file in the tree and line to open it at.
+// Directories are opened at the file named like the package directory or at the first go file.
+func resolveEditorTarget(root tree.Node, modulePath string, target editorTarget) (string, int, error) {
+	path := target.Path
+	line := 1
+
+	switch {
+	case target.Func != "":
+		declPath, declLine, err := findDeclaration(root, modulePath, target.Pkg, target.Func)
+		if err != nil {
+			return "", 0, err
+		}
+		path, line = declPath, declLine
+	case target.Pkg != "":
+		path = packageDir(target.Pkg, modulePath)
+		if path == "" {
+			return "", 0, fmt.Errorf("%w: '%s' is outside the module", ErrSourceNotFound, target.Pkg)
+		}
+	}
+
+	node, ok := findTreeNode(root, filepath.Clean(filepath.FromSlash(path)))
+	if ok && node.IsDir {
+		path = packageFile(node)
+	}
+
+	file, err := sourceFile(root, path)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return file.AbsPath, line, nil
+}
+
+// escapePath escapes segments of the file path for URIs, e.g. spaces, '#' and '?' in directory names.
+func escapePath(file string) string {
+	segments := strings.Split(filepath.ToSlash(file), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func packageFile(dir tree.Node) string {
+	var first string
+	for _, child := range dir.Children {
+		if child.IsDir || !strings.HasSuffix(child.Name, ".go") || strings.HasSuffix(child.Name, "_test.go") {
+			continue
+		}
+
+		if child.Name == dir.Name+".go" {
+			return child.Path
+		}
+
+		if first == "" {
+			first = child.Path
+		}
+	}
+
+	return first
+}
+
+// openInEditor launches editor command or returns URI for templates like 'vscode://file/{file}:{line}'.
+func openInEditor(template string, file string, line int) (string, error) {
+	replacer := strings.NewReplacer("{file}", file, "{line}", strconv.Itoa(line))
+
+	if strings.Contains(template, "://") {
+		uriReplacer := strings.NewReplacer("{file}", escapePath(file), "{line}", strconv.Itoa(line))
+		return uriReplacer.Replace(template), nil
+	}
+
+	if err := checkEditor(template); err != nil {
+		return "", err
+	}
+
+	// Placeholders are replaced in split arguments, so paths with spaces stay single arguments.
+	args := strings.Fields(template)
+	for i := range args {
+		args[i] = replacer.Replace(args[i])
+	}
+	if len(args) == 0 {
+		return "", errors.New("empty editor command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("start '%s': %w", cmd.Path, err)
+	}
+
+	// Do not leave zombie process.
+	go cmd.Wait()
+
+	return "", nil
+}
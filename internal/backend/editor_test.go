@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+func TestEditorTemplate(t *testing.T) {
+	t.Setenv("EDITOR", "vim")
+
+	assert.Equal(t, "vim {file}", editorTemplate(""))
+	assert.Equal(t, "code -g {file}:{line}", editorTemplate("code"))
+	assert.Equal(t, "subl {file}:{line}", editorTemplate("subl {file}:{line}"))
+}
+
+func TestOpenInEditorURI(t *testing.T) {
+	// act
+	uri, err := openInEditor("vscode://file/{file}:{line}", "/src/app/main.go", 12)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "vscode://file//src/app/main.go:12", uri)
+}
+
+func TestOpenInEditorURIEscapesPath(t *testing.T) {
+	// act
+	uri, err := openInEditor("vscode://file/{file}:{line}", "/src/my app/#1?/100%/main.go", 12)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "vscode://file//src/my%20app/%231%3F/100%25/main.go:12", uri)
+}
+
+func TestOpenInTerminalEditor(t *testing.T) {
+	// act
+	_, err := openInEditor(editorTemplate("/usr/bin/vim"), "/src/app/main.go", 12)
+
+	// assert
+	assert.ErrorIs(t, err, ErrTerminalEditor)
+	assert.NoError(t, checkEditor("code -g {file}:{line}"))
+	assert.NoError(t, checkEditor("vscode://file/{file}:{line}"))
+}
+
+func TestCheckLocalRequest(t *testing.T) {
+	request := func(remoteAddr string, host string, origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/open", nil)
+		r.RemoteAddr, r.Host = remoteAddr, host
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	assert.NoError(t, checkLocalRequest(request("127.0.0.1:50000", "localhost:9798", "http://localhost:9798")))
+	assert.NoError(t, checkLocalRequest(request("[::1]:50000", "[::1]:9798", "")))
+	assert.Error(t, checkLocalRequest(request("192.168.1.7:50000", "192.168.1.2:9798", "")), "other host")
+	assert.Error(t, checkLocalRequest(request("127.0.0.1:50000", "evil.example.com:9798", "")), "rebound name")
+	assert.Error(t, checkLocalRequest(request("127.0.0.1:50000", "localhost:9798", "http://evil.example.com")), "other site")
+}
+
+func TestResolveEditorTarget(t *testing.T) {
+	// arrange
+	t.Chdir(t.TempDir())
+	assert.NoError(t, os.Mkdir("worker", 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join("worker", "a.go"), []byte("package worker\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join("worker", "worker.go"), []byte("package worker\n\nfunc Run() {}\n"), 0o644))
+
+	root, err := tree.BuildTree(".", false)
+	assert.NoError(t, err)
+
+	workerFile, err := filepath.Abs(filepath.Join("worker", "worker.go"))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		target editorTarget
+		line   int
+	}{
+		{name: "file", target: editorTarget{Path: "worker/worker.go"}, line: 1},
+		{name: "directory", target: editorTarget{Path: "worker"}, line: 1},
+		{name: "package", target: editorTarget{Pkg: "example.com/app/worker"}, line: 1},
+		{name: "function", target: editorTarget{Pkg: "example.com/app/worker", Func: "Run"}, line: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// act
+			file, line, err := resolveEditorTarget(root, "example.com/app", tt.target)
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, workerFile, file)
+			assert.Equal(t, tt.line, line)
+		})
+	}
+
+	_, _, err = resolveEditorTarget(root, "example.com/app", editorTarget{Pkg: "golang.org/x/text"})
+	assert.ErrorIs(t, err, ErrSourceNotFound)
+}
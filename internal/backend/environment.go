@@ -0,0 +1,338 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CheckStatus string
+
+const (
+	CheckOK      CheckStatus = "ok"
+	CheckWarning CheckStatus = "warning"
+	CheckFailed  CheckStatus = "failed"
+)
+
+// Environment check names.
+const (
+	checkDot         = "graphviz dot"
+	checkDotSVG      = "graphviz svg output"
+	checkEmbedded    = "embedded graphviz"
+	checkRenderer    = "graph renderer"
+	checkGoToolchain = "go toolchain"
+	checkGoModule    = "go module"
+)
+
+// minDotVersion is the oldest graphviz version known to render goda output properly.
+var minDotVersion = [2]int{2, 40}
+
+var dotVersionRe = regexp.MustCompile(`version (\d+)\.(\d+)`)
+
+// probeGraph is the smallest graph to check rendering.
+var probeGraph = []byte("digraph G { a -> b; }")
+
+var ErrUnhealthyEnvironment = errors.New("environment is not suitable")
+
+// healthTTL is how long /health serves the last report before probing the environment again.
+// Probing runs graphviz and go, so it isn't done on every request.
+const healthTTL = time.Minute
+
+// Check is a result of a single environment check.
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+	// Hint tells what to do when check isn't ok.
+	Hint string `json:"hint,omitempty"`
+}
+
+// EnvironmentReport describes tools goda, go-callvis and renderers rely on.
+type EnvironmentReport struct {
+	Checks []Check `json:"checks"`
+}
+
+// Healthy reports whether the app can work in the environment.
+// Warnings degrade experience but don't prevent the app from working.
+func (r EnvironmentReport) Healthy() bool {
+	for _, check := range r.Checks {
+		if check.Status == CheckFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r EnvironmentReport) Check(name string) Check {
+	for _, check := range r.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+
+	return Check{Name: name, Status: CheckFailed, Detail: "not checked"}
+}
+
+func (r EnvironmentReport) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.NewBuffer(nil)
+	for _, check := range r.Checks {
+		fmt.Fprintf(buf, "%-9s %-20s %s\n", "["+string(check.Status)+"]", check.Name, check.Detail)
+		if check.Hint != "" {
+			fmt.Fprintf(buf, "%-9s %-20s hint: %s\n", "", "", check.Hint)
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// Doctor prints environment diagnostics report of the module directory.
+func Doctor(cfg Config, w io.Writer) error {
+	if err := enterModuleDir(&cfg); err != nil {
+		return err
+	}
+
+	report := probeEnvironment(context.Background())
+	if _, err := report.WriteTo(w); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	if !report.Healthy() {
+		return ErrUnhealthyEnvironment
+	}
+
+	return nil
+}
+
+// checkEnvironment probes the host and chooses graph renderer, the probe report is returned for health checks.
+func checkEnvironment(ctx context.Context, rendererName string) (Renderer, EnvironmentReport, error) {
+	report := probeEnvironment(ctx)
+	if !report.Healthy() {
+		report.WriteTo(os.Stderr)
+		return nil, EnvironmentReport{}, ErrUnhealthyEnvironment
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != CheckOK {
+			log.Printf("%s: %s: %s. %s", check.Status, check.Name, check.Detail, check.Hint)
+		}
+	}
+
+	dotSVG := report.Check(checkDotSVG)
+	switch rendererName {
+	case RendererAuto, "":
+		if dotSVG.Status != CheckOK {
+			rendererName = RendererEmbedded
+		}
+	case RendererDot:
+		if dotSVG.Status != CheckOK {
+			return nil, EnvironmentReport{}, fmt.Errorf("%s: %s", checkDotSVG, dotSVG.Detail)
+		}
+	}
+
+	renderer, err := newRenderer(rendererName)
+	if err != nil {
+		return nil, EnvironmentReport{}, fmt.Errorf("new renderer: %w", err)
+	}
+
+	return renderer, report, nil
+}
+
+// healthCache keeps the last environment report.
+type healthCache struct {
+	mu       sync.Mutex
+	report   EnvironmentReport
+	probedAt time.Time
+	probe    func(ctx context.Context) EnvironmentReport
+}
+
+// newHealthCache starts with the report just probed at startup.
+func newHealthCache(report EnvironmentReport) *healthCache {
+	return &healthCache{report: report, probedAt: time.Now(), probe: probeEnvironment}
+}
+
+// Report returns the last report, the environment is probed again once the report is older than healthTTL.
+func (c *healthCache) Report(ctx context.Context) EnvironmentReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.probedAt) >= healthTTL {
+		// Canceled request shouldn't leave failed checks for others.
+		c.report = c.probe(context.WithoutCancel(ctx))
+		c.probedAt = time.Now()
+	}
+
+	return c.report
+}
+
+// healthHandler responds with environment report, status is 503 when environment is unhealthy.
+func healthHandler(cache *healthCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := cache.Report(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// probeEnvironment checks tools in the current working directory.
+func probeEnvironment(ctx context.Context) EnvironmentReport {
+	var report EnvironmentReport
+
+	dot, dotSVG := probeDot(ctx)
+	embedded := probeEmbedded(ctx)
+
+	renderer := Check{Name: checkRenderer, Status: CheckOK}
+	switch {
+	case dotSVG.Status == CheckOK:
+		renderer.Detail = "graphviz dot"
+	case embedded.Status == CheckOK:
+		renderer.Detail = "embedded graphviz"
+	default:
+		renderer.Status = CheckFailed
+		renderer.Detail = "no working renderer"
+		renderer.Hint = "install graphviz: https://graphviz.org/download/"
+	}
+
+	report.Checks = append(report.Checks, dot, dotSVG, embedded, renderer)
+	report.Checks = append(report.Checks, probeGo(ctx)...)
+
+	return report
+}
+
+func probeDot(ctx context.Context) (Check, Check) {
+	dot := Check{Name: checkDot}
+	svg := Check{Name: checkDotSVG, Status: CheckWarning, Detail: "skipped"}
+
+	path, err := exec.LookPath("dot")
+	if err != nil {
+		dot.Status = CheckWarning
+		dot.Detail = "not found in PATH"
+		dot.Hint = "install graphviz for faster rendering: https://graphviz.org/download/"
+		return dot, svg
+	}
+
+	// 'dot -V' prints version to stderr.
+	output, err := exec.CommandContext(ctx, path, "-V").CombinedOutput()
+	if err != nil {
+		dot.Status = CheckWarning
+		dot.Detail = fmt.Sprintf("%s: 'dot -V' failed: %s: %s", path, err, strings.TrimSpace(string(output)))
+		dot.Hint = "reinstall graphviz"
+		return dot, svg
+	}
+
+	version, err := parseDotVersion(string(output))
+	switch {
+	case err != nil:
+		dot.Status = CheckWarning
+		dot.Detail = fmt.Sprintf("%s: %s", path, err)
+	case version[0] < minDotVersion[0] || (version[0] == minDotVersion[0] && version[1] < minDotVersion[1]):
+		dot.Status = CheckWarning
+		dot.Detail = fmt.Sprintf("%s: version %d.%d is outdated", path, version[0], version[1])
+		dot.Hint = fmt.Sprintf("update graphviz to %d.%d or newer", minDotVersion[0], minDotVersion[1])
+	default:
+		dot.Status = CheckOK
+		dot.Detail = fmt.Sprintf("%s: version %d.%d", path, version[0], version[1])
+	}
+
+	image, err := dotRenderer{path: path}.RenderSVG(ctx, probeGraph)
+	switch {
+	case err != nil:
+		svg.Detail = err.Error()
+		svg.Hint = "install graphviz with svg support (e.g. 'librsvg' or full graphviz package)"
+	case !bytes.Contains(image, []byte("<svg")):
+		svg.Detail = "output is not svg"
+		svg.Hint = "install graphviz with svg support (e.g. 'librsvg' or full graphviz package)"
+	default:
+		svg.Status = CheckOK
+		svg.Detail = "supported"
+	}
+
+	return dot, svg
+}
+
+func probeEmbedded(ctx context.Context) Check {
+	check := Check{Name: checkEmbedded}
+
+	image, err := embeddedRenderer{}.RenderSVG(ctx, probeGraph)
+	switch {
+	case err != nil:
+		check.Status = CheckWarning
+		check.Detail = err.Error()
+	case !bytes.Contains(image, []byte("<svg")):
+		check.Status = CheckWarning
+		check.Detail = "output is not svg"
+	default:
+		check.Status = CheckOK
+		check.Detail = "available"
+	}
+
+	return check
+}
+
+// probeGo checks toolchain and module, goda and go-callvis load packages with 'go list'.
+func probeGo(ctx context.Context) []Check {
+	toolchain := Check{Name: checkGoToolchain}
+	module := Check{Name: checkGoModule, Status: CheckFailed, Detail: "skipped"}
+
+	path, err := exec.LookPath("go")
+	if err != nil {
+		toolchain.Status = CheckFailed
+		toolchain.Detail = "not found in PATH"
+		toolchain.Hint = "install go: https://go.dev/doc/install"
+		return []Check{toolchain, module}
+	}
+
+	version, err := exec.CommandContext(ctx, path, "env", "GOVERSION").Output()
+	if err != nil {
+		toolchain.Status = CheckFailed
+		toolchain.Detail = fmt.Sprintf("%s: 'go env' failed: %s", path, err)
+		toolchain.Hint = "reinstall go: https://go.dev/doc/install"
+		return []Check{toolchain, module}
+	}
+
+	toolchain.Status = CheckOK
+	toolchain.Detail = fmt.Sprintf("%s: %s", path, strings.TrimSpace(string(version)))
+
+	output, err := exec.CommandContext(ctx, path, "list", "-m").CombinedOutput()
+	if err != nil {
+		module.Detail = strings.TrimSpace(string(output))
+		module.Hint = "run inside go module or pass its root with '-dir'"
+		return []Check{toolchain, module}
+	}
+
+	module.Status = CheckOK
+	module.Detail = strings.TrimSpace(string(output))
+
+	return []Check{toolchain, module}
+}
+
+// parseDotVersion parses major and minor version of 'dot -V' output,
+// e.g. 'dot - graphviz version 2.43.0 (0)'.
+func parseDotVersion(output string) ([2]int, error) {
+	match := dotVersionRe.FindStringSubmatch(output)
+	if match == nil {
+		return [2]int{}, fmt.Errorf("unknown version format '%s'", strings.TrimSpace(output))
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+
+	return [2]int{major, minor}, nil
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotVersion(t *testing.T) {
+	t.Run("old format", func(t *testing.T) {
+		got, err := parseDotVersion("dot - graphviz version 2.43.0 (0)\n")
+		assert.NoError(t, err)
+		assert.Equal(t, [2]int{2, 43}, got)
+	})
+
+	t.Run("new format", func(t *testing.T) {
+		got, err := parseDotVersion("dot - graphviz version 12.2.1 (20241206.2353)\n")
+		assert.NoError(t, err)
+		assert.Equal(t, [2]int{12, 2}, got)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := parseDotVersion("dot: command not found")
+		assert.Error(t, err)
+	})
+}
+
+func TestEnvironmentReport(t *testing.T) {
+	// arrange
+	report := EnvironmentReport{
+		Checks: []Check{
+			{Name: checkDot, Status: CheckWarning, Detail: "not found in PATH", Hint: "install graphviz"},
+			{Name: checkGoToolchain, Status: CheckOK, Detail: "/usr/bin/go: go1.24.5"},
+		},
+	}
+
+	want := "" +
+		"[warning] graphviz dot         not found in PATH\n" +
+		"                               hint: install graphviz\n" +
+		"[ok]      go toolchain         /usr/bin/go: go1.24.5\n"
+
+	// act
+	buf := bytes.NewBuffer(nil)
+	_, err := report.WriteTo(buf)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, CheckOK, report.Check(checkGoToolchain).Status)
+	assert.Equal(t, CheckFailed, report.Check(checkGoModule).Status)
+
+	report.Checks = append(report.Checks, Check{Name: checkGoModule, Status: CheckFailed})
+	assert.False(t, report.Healthy())
+}
+
+func TestHealthHandler(t *testing.T) {
+	// arrange
+	probes := 0
+	cache := newHealthCache(EnvironmentReport{Checks: []Check{{Name: checkGoModule, Status: CheckOK}}})
+	cache.probe = func(ctx context.Context) EnvironmentReport {
+		probes++
+		return EnvironmentReport{Checks: []Check{{Name: checkGoModule, Status: CheckFailed}}}
+	}
+	handler := healthHandler(cache)
+	get := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+		return recorder.Code
+	}
+
+	// act
+	fresh := get()
+	cache.probedAt = time.Now().Add(-healthTTL)
+	expired := get()
+	cached := get()
+
+	// assert
+	assert.Equal(t, http.StatusOK, fresh, "startup report is served")
+	assert.Equal(t, http.StatusServiceUnavailable, expired)
+	assert.Equal(t, http.StatusServiceUnavailable, cached)
+	assert.Equal(t, 1, probes, "environment is probed once the report expires")
+}
@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const callvisExportDir = "callvis"
+
+// staticDocument is a pre-rendered page, either written next to the exported page
+// or inlined into it.
+type staticDocument struct {
+	URL         string `json:"url,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Content     string `json:"content,omitempty"`
+}
+
+// graphNodeTitleRe matches package path graphviz writes as a node link tooltip.
+var graphNodeTitleRe = regexp.MustCompile(`<g id="a_node[^"]*"><a [^>]*xlink:title="([^"]*)"`)
+
+// Export writes self-contained html report instead of hosting it.
+// Output ending with '.html' is written as a single file with call graphs inlined,
+// otherwise output is a directory with 'index.html' and call graphs next to it.
+func Export(cfg Config, output string) error {
+	ctx := context.Background()
+
+	// Resolve before prepare changes working directory.
+	output, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("absolute output path: %w", err)
+	}
+
+	currentDirTree, opts, err := prepare(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	pageViews, err := buildViews(ctx, currentDirTree, opts)
+	if err != nil {
+		return err
+	}
+
+	singleFile := strings.HasSuffix(output, ".html")
+	outputDir := output
+	if singleFile {
+		outputDir = filepath.Dir(output)
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
+	var settings pageSettings
+	settings.setViews(pageViews)
+
+	// Call graphs are exported for the default main package only.
+	callvisHandler, err := goCallvisHandlers(ctx, pageViews.modulePath, opts.algo, opts.renderer)
+	if err != nil {
+		log.Println("do not export go-callvis. failed to get go-callvis handler: ", err)
+	} else {
+		log.Println("render call graphs")
+		settings.StaticCallvis, err = exportCallvis(callvisHandler, currentDirTree.AbsPath, graphPackages(pageViews.depsSVG), outputDir, singleFile)
+		if err != nil {
+			return fmt.Errorf("export call graphs: %w", err)
+		}
+	}
+
+	log.Println("create html")
+	htmlPage, err := composeHTML(pageViews.treeHTML, pageViews.depsSVG, settings)
+	if err != nil {
+		return fmt.Errorf("compose html: %w", err)
+	}
+
+	pagePath := output
+	if !singleFile {
+		pagePath = filepath.Join(outputDir, "index.html")
+	}
+
+	if err := os.WriteFile(pagePath, htmlPage, 0o644); err != nil {
+		return fmt.Errorf("write page: %w", err)
+	}
+
+	log.Println("exported to", pagePath)
+
+	return nil
+}
+
+// graphPackages returns package paths of dependency graph nodes.
+func graphPackages(graphSVG string) []string {
+	var packages []string
+	for _, match := range graphNodeTitleRe.FindAllStringSubmatch(graphSVG, -1) {
+		pkg := html.UnescapeString(match[1])
+		// Collapsed directories have no call graphs.
+		if strings.HasSuffix(pkg, aggregateSuffix) {
+			continue
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return packages
+}
+
+// exportCallvis renders call graph of every package with the same request the page makes on "c" click.
+func exportCallvis(handler http.Handler, rootPkg string, packages []string, outputDir string, inline bool) (map[string]staticDocument, error) {
+	documents := make(map[string]staticDocument, len(packages))
+	for _, pkg := range packages {
+		query := url.Values{"limit": {rootPkg}, "f": {pkg}}
+		content, contentType, err := renderHandler(handler, "/callvis?"+query.Encode())
+		if err != nil {
+			// One broken package shouldn't break the whole report.
+			log.Printf("skip call graph of '%s': %s", pkg, err)
+			continue
+		}
+
+		if inline {
+			documents[pkg] = staticDocument{ContentType: contentType, Content: string(content)}
+			continue
+		}
+
+		relativePath := path.Join(callvisExportDir, pkg+documentExtension(contentType))
+		filePath := filepath.Join(outputDir, filepath.FromSlash(relativePath))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			return nil, fmt.Errorf("create directory for '%s': %w", pkg, err)
+		}
+
+		if err := os.WriteFile(filePath, content, 0o644); err != nil {
+			return nil, fmt.Errorf("write call graph of '%s': %w", pkg, err)
+		}
+
+		documents[pkg] = staticDocument{URL: relativePath, ContentType: contentType}
+	}
+
+	return documents, nil
+}
+
+// renderHandler performs in-process request to the handler.
+func renderHandler(handler http.Handler, target string) ([]byte, string, error) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	if recorder.Code != http.StatusOK {
+		return nil, "", fmt.Errorf("status '%d': %s", recorder.Code, strings.TrimSpace(recorder.Body.String()))
+	}
+
+	contentType := recorder.Header().Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(recorder.Body.Bytes())
+	}
+
+	return recorder.Body.Bytes(), contentType, nil
+}
+
+func documentExtension(contentType string) string {
+	if strings.HasPrefix(contentType, "image/svg+xml") {
+		return ".svg"
+	}
+
+	return ".html"
+}
@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphPackages(t *testing.T) {
+	// arrange
+	input := `<svg id="svg" width="200pt">
+<g id="graph0" class="graph">
+<!-- github.com/username/tmp/cmd/app -->
+<g id="node1" class="node">
+<title>github.com/username/tmp/cmd/app</title>
+<g id="a_node1"><a xlink:href="https://pkg.go.dev/github.com/username/tmp/cmd/app" xlink:title="github.com/username/tmp/cmd/app">
+<polygon fill="none" stroke="black" points="0,0 1,1"/>
+</a>
+</g>
+</g>
+<!-- github.com/username/tmp/internal/a&amp;b -->
+<g id="node2" class="node">
+<title>github.com/username/tmp/internal/a&amp;b</title>
+<g id="a_node2"><a xlink:href="https://pkg.go.dev/github.com/username/tmp/internal/a&amp;b" xlink:title="github.com/username/tmp/internal/a&amp;b">
+<polygon fill="none" stroke="black" points="0,0 1,1"/>
+</a>
+</g>
+</g>
+<g id="edge1" class="edge">
+<title>github.com/username/tmp/cmd/app:e&#45;&gt;github.com/username/tmp/internal/a&amp;b</title>
+<g id="a_edge1"><a xlink:title="edge tooltip">
+<path fill="none" stroke="black" d="M0,0"/>
+</a>
+</g>
+</g>
+</g>
+</svg>`
+
+	want := []string{
+		"github.com/username/tmp/cmd/app",
+		"github.com/username/tmp/internal/a&b",
+	}
+
+	// act
+	got := graphPackages(input)
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestDocumentExtension(t *testing.T) {
+	assert.Equal(t, ".svg", documentExtension("image/svg+xml"))
+	assert.Equal(t, ".svg", documentExtension("image/svg+xml; charset=utf-8"))
+	assert.Equal(t, ".html", documentExtension("text/html; charset=utf-8"))
+	assert.Equal(t, ".html", documentExtension(""))
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var ErrBadExpression = errors.New("bad package expression")
+
+// expressionGraphs renders dependency graphs of goda package expressions. Goda loads packages on every run,
+// so graphs are cached by expression. Every snapshot of the page has its own cache, so changed sources are reloaded.
+type expressionGraphs struct {
+	renderer Renderer
+	loadDOT  func(ctx context.Context, expr string) ([]byte, error)
+
+	mu     sync.Mutex
+	graphs map[string]*expressionGraph
+}
+
+// expressionGraph is a graph of an expression rendered once, requests of other expressions don't wait for it.
+type expressionGraph struct {
+	once sync.Once
+	svg  string
+	err  error
+}
+
+func newExpressionGraphs(renderer Renderer) *expressionGraphs {
+	return &expressionGraphs{
+		renderer: renderer,
+		loadDOT: func(ctx context.Context, expr string) ([]byte, error) {
+			_, dot, err := loadDepsGraph(ctx, expr)
+			return dot, err
+		},
+		graphs: make(map[string]*expressionGraph),
+	}
+}
+
+// svg returns cached graph image of the expression, rendering it on the first call.
+// Failed graphs aren't cached, e.g. ones of canceled requests.
+func (g *expressionGraphs) svg(ctx context.Context, expr string) (string, error) {
+	g.mu.Lock()
+	entry, ok := g.graphs[expr]
+	if !ok {
+		entry = &expressionGraph{}
+		g.graphs[expr] = entry
+	}
+	g.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.svg, entry.err = g.render(ctx, expr)
+	})
+
+	if entry.err != nil {
+		g.mu.Lock()
+		if g.graphs[expr] == entry {
+			delete(g.graphs, expr)
+		}
+		g.mu.Unlock()
+
+		return "", entry.err
+	}
+
+	return entry.svg, nil
+}
+
+func (g *expressionGraphs) render(ctx context.Context, expr string) (string, error) {
+	log.Printf("build deps graph of '%s'", expr)
+	dot, err := g.loadDOT(ctx, expr)
+	if err != nil {
+		// Goda fails mostly on expressions it can't parse or resolve.
+		return "", fmt.Errorf("%w: %w", ErrBadExpression, err)
+	}
+
+	depsSVG, err := renderDepsGraph(ctx, g.renderer, dot)
+	if err != nil {
+		return "", fmt.Errorf("render graph: %w", err)
+	}
+
+	return depsSVG, nil
+}
+
+// registerGraphExpression adds dependency graph image of goda package expression, e.g. './...:-test'.
+// Goda output is rendered as is, without analysis overlays and collapsed directories.
+func registerGraphExpression(mux *http.ServeMux, page *livePage) {
+	mux.Handle("GET /graph", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expr := strings.TrimSpace(r.URL.Query().Get("expr"))
+
+		depsSVG, err := page.snapshot().expressions.svg(r.Context(), expr)
+		if errors.Is(err, ErrBadExpression) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Println("render expression graph failed: ", err)
+			http.Error(w, "render graph failed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(depsSVG))
+	}))
+}
@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphExpression(t *testing.T) {
+	// arrange
+	loaded := map[string]int{}
+	graphs := newExpressionGraphs(echoRenderer{})
+	graphs.loadDOT = func(_ context.Context, expr string) ([]byte, error) {
+		loaded[expr]++
+		if expr == "bad(" {
+			return nil, errors.New("parse expression")
+		}
+		return []byte("digraph G {}"), nil
+	}
+
+	mux := http.NewServeMux()
+	registerGraphExpression(mux, newLivePage(snapshot{expressions: graphs}))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	// act
+	first := serve("/graph?expr=./...:-test")
+	second := serve("/graph?expr=./...:-test")
+	serve("/graph?expr=bad(")
+	bad := serve("/graph?expr=bad(")
+
+	// assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "image/svg+xml", first.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, http.StatusBadRequest, bad.Code)
+	assert.Equal(t, map[string]int{"./...:-test": 1, "bad(": 2}, loaded, "graphs must be cached, failed ones retried")
+}
@@ -0,0 +1,79 @@
+package graph
+
+// Change is a difference of a node or an edge between two graph versions.
+type Change string
+
+const (
+	Unchanged Change = "unchanged"
+	Added     Change = "added"
+	Removed   Change = "removed"
+)
+
+// Diff is a graph merged from two versions with changes of its nodes and edges.
+type Diff struct {
+	Merged Graph
+	Nodes  map[string]Change
+	Edges  map[[2]string]Change
+}
+
+// Compare merges base and head versions of the graph.
+// Nodes and edges present in both versions keep head attributes,
+// removed ones are appended after head ones. Default attributes are taken from head.
+func Compare(base Graph, head Graph) Diff {
+	diff := Diff{
+		Merged: Graph{
+			GraphAttrs: head.GraphAttrs,
+			NodeAttrs:  head.NodeAttrs,
+			EdgeAttrs:  head.EdgeAttrs,
+			GraphHTML:  head.GraphHTML,
+			NodeHTML:   head.NodeHTML,
+			EdgeHTML:   head.EdgeHTML,
+		},
+		Nodes: make(map[string]Change),
+		Edges: make(map[[2]string]Change),
+	}
+
+	baseNodes := make(map[string]bool, len(base.Nodes))
+	for _, node := range base.Nodes {
+		baseNodes[node.ID] = true
+	}
+
+	baseEdges := make(map[[2]string]bool, len(base.Edges))
+	for _, edge := range base.Edges {
+		baseEdges[[2]string{edge.From, edge.To}] = true
+	}
+
+	for _, node := range head.Nodes {
+		diff.Merged.Nodes = append(diff.Merged.Nodes, node)
+		diff.Nodes[node.ID] = Added
+		if baseNodes[node.ID] {
+			diff.Nodes[node.ID] = Unchanged
+		}
+	}
+
+	for _, edge := range head.Edges {
+		key := [2]string{edge.From, edge.To}
+		diff.Merged.Edges = append(diff.Merged.Edges, edge)
+		diff.Edges[key] = Added
+		if baseEdges[key] {
+			diff.Edges[key] = Unchanged
+		}
+	}
+
+	for _, node := range base.Nodes {
+		if _, ok := diff.Nodes[node.ID]; !ok {
+			diff.Merged.Nodes = append(diff.Merged.Nodes, node)
+			diff.Nodes[node.ID] = Removed
+		}
+	}
+
+	for _, edge := range base.Edges {
+		key := [2]string{edge.From, edge.To}
+		if _, ok := diff.Edges[key]; !ok {
+			diff.Merged.Edges = append(diff.Merged.Edges, edge)
+			diff.Edges[key] = Removed
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	// arrange
+	base := Graph{
+		Nodes: []Node{{ID: "a"}, {ID: "b"}, {ID: "old"}},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "a", To: "old"}},
+	}
+	head := Graph{
+		Nodes: []Node{{ID: "a"}, {ID: "b", Attrs: map[string]string{"label": "b"}}, {ID: "new"}},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "b", To: "new"}},
+	}
+
+	// act
+	got := Compare(base, head)
+
+	// assert
+	assert.Equal(t, Graph{
+		Nodes: []Node{{ID: "a"}, {ID: "b", Attrs: map[string]string{"label": "b"}}, {ID: "new"}, {ID: "old"}},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "b", To: "new"}, {From: "a", To: "old"}},
+	}, got.Merged)
+	assert.Equal(t, map[string]Change{"a": Unchanged, "b": Unchanged, "new": Added, "old": Removed}, got.Nodes)
+	assert.Equal(t, map[[2]string]Change{
+		{"a", "b"}:   Unchanged,
+		{"b", "new"}: Added,
+		{"a", "old"}: Removed,
+	}, got.Edges)
+}
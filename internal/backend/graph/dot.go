@@ -0,0 +1,537 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var ErrSyntax = errors.New("dot syntax error")
+
+type tokenKind int
+
+const (
+	tokenID tokenKind = iota
+	// tokenHTML is an html-like string, value is without outer angle brackets.
+	tokenHTML
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// ParseDOT parses graph in graphviz dot language as goda writes it:
+// node and edge statements with attributes.
+// Subgraphs are flattened: their nodes and edges are added to the graph, but subgraphs themselves,
+// clusters included, and their attributes aren't kept, so the graph is written back without them.
+func ParseDOT(data []byte) (Graph, error) {
+	tokens, err := tokenize(data)
+	if err != nil {
+		return Graph{}, err
+	}
+
+	p := parser{
+		tokens: tokens,
+		nodes:  map[string]int{},
+		graph: Graph{
+			GraphAttrs: map[string]string{},
+			NodeAttrs:  map[string]string{},
+			EdgeAttrs:  map[string]string{},
+		},
+	}
+
+	if err := p.parseGraph(); err != nil {
+		return Graph{}, err
+	}
+
+	return p.graph, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	graph  Graph
+	// nodes maps node id to its index in graph nodes.
+	nodes map[string]int
+	depth int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+
+	return t, ok
+}
+
+func (p *parser) isPunct(value string) bool {
+	t, ok := p.peek()
+	return ok && t.kind == tokenPunct && t.value == value
+}
+
+func (p *parser) expect(value string) error {
+	t, ok := p.next()
+	if !ok {
+		return fmt.Errorf("%w: expected '%s', got end of input", ErrSyntax, value)
+	}
+
+	if t.kind != tokenPunct || t.value != value {
+		return fmt.Errorf("%w: expected '%s', got '%s'", ErrSyntax, value, t.value)
+	}
+
+	return nil
+}
+
+func (p *parser) parseGraph() error {
+	if t, ok := p.peek(); ok && t.kind == tokenID && strings.EqualFold(t.value, "strict") {
+		p.next()
+	}
+
+	t, ok := p.next()
+	if !ok || t.kind != tokenID || (!strings.EqualFold(t.value, "digraph") && !strings.EqualFold(t.value, "graph")) {
+		return fmt.Errorf("%w: expected 'digraph'", ErrSyntax)
+	}
+
+	// Optional graph name.
+	if !p.isPunct("{") {
+		p.next()
+	}
+
+	return p.parseBlock()
+}
+
+func (p *parser) parseBlock() error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	for {
+		if p.isPunct("}") {
+			p.next()
+			return nil
+		}
+
+		if err := p.parseStatement(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) parseStatement() error {
+	t, ok := p.peek()
+	if !ok {
+		return fmt.Errorf("%w: unexpected end of input", ErrSyntax)
+	}
+
+	switch {
+	case t.kind == tokenPunct && t.value == ";":
+		p.next()
+		return nil
+	case t.kind == tokenPunct && t.value == "{":
+		return p.parseBlock()
+	case t.kind == tokenPunct:
+		return fmt.Errorf("%w: unexpected '%s'", ErrSyntax, t.value)
+	}
+
+	keyword := strings.ToLower(t.value)
+	if t.kind == tokenID {
+		switch keyword {
+		case "subgraph":
+			p.next()
+			if !p.isPunct("{") {
+				p.next()
+			}
+			return p.parseBlock()
+		case "graph", "node", "edge":
+			p.next()
+			attrs, html, err := p.parseAttrs()
+			if err != nil {
+				return err
+			}
+
+			// Nested subgraph defaults don't apply to the whole graph.
+			if p.depth == 1 {
+				switch keyword {
+				case "graph":
+					p.graph.GraphHTML = setAttrs(p.graph.GraphAttrs, p.graph.GraphHTML, attrs, html)
+				case "node":
+					p.graph.NodeHTML = setAttrs(p.graph.NodeAttrs, p.graph.NodeHTML, attrs, html)
+				case "edge":
+					p.graph.EdgeHTML = setAttrs(p.graph.EdgeAttrs, p.graph.EdgeHTML, attrs, html)
+				}
+			}
+			return nil
+		}
+	}
+
+	p.next()
+	id := t.value
+
+	// Graph attribute 'key = value'.
+	if p.isPunct("=") {
+		p.next()
+		value, ok := p.next()
+		if !ok {
+			return fmt.Errorf("%w: expected value of '%s'", ErrSyntax, id)
+		}
+		if p.depth == 1 {
+			p.graph.GraphHTML = setAttrs(p.graph.GraphAttrs, p.graph.GraphHTML,
+				map[string]string{id: value.value}, map[string]bool{id: value.kind == tokenHTML})
+		}
+		return nil
+	}
+
+	chain := []string{id}
+	ports := []string{p.parsePort()}
+	for p.isPunct("->") || p.isPunct("--") {
+		p.next()
+		to, ok := p.next()
+		if !ok || to.kind == tokenPunct {
+			return fmt.Errorf("%w: expected edge target after '%s'", ErrSyntax, id)
+		}
+		chain = append(chain, to.value)
+		ports = append(ports, p.parsePort())
+	}
+
+	attrs, html, err := p.parseAttrs()
+	if err != nil {
+		return err
+	}
+
+	if len(chain) == 1 {
+		p.addNode(id, attrs, html)
+		return nil
+	}
+
+	for i := 0; i < len(chain)-1; i++ {
+		p.addNode(chain[i], nil, nil)
+		p.addNode(chain[i+1], nil, nil)
+		p.graph.Edges = append(p.graph.Edges, Edge{
+			From:     chain[i],
+			To:       chain[i+1],
+			Attrs:    copyAttrs(attrs),
+			FromPort: ports[i],
+			ToPort:   ports[i+1],
+			HTML:     maps.Clone(html),
+		})
+	}
+
+	return nil
+}
+
+// parsePort parses node port and compass point, e.g. '"pkg":e'.
+func (p *parser) parsePort() string {
+	var parts []string
+	for p.isPunct(":") {
+		p.next()
+		if t, ok := p.next(); ok {
+			parts = append(parts, t.value)
+		}
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// parseAttrs parses attribute lists, html-like values are marked in the returned set.
+func (p *parser) parseAttrs() (map[string]string, map[string]bool, error) {
+	var attrs map[string]string
+	var html map[string]bool
+	for p.isPunct("[") {
+		p.next()
+		if attrs == nil {
+			attrs = map[string]string{}
+		}
+
+		for !p.isPunct("]") {
+			key, ok := p.next()
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: unclosed attribute list", ErrSyntax)
+			}
+			if key.kind == tokenPunct && (key.value == "," || key.value == ";") {
+				continue
+			}
+
+			if !p.isPunct("=") {
+				attrs[key.value] = "true"
+				continue
+			}
+			p.next()
+
+			value, ok := p.next()
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: expected value of '%s'", ErrSyntax, key.value)
+			}
+			attrs[key.value] = value.value
+
+			delete(html, key.value)
+			if value.kind == tokenHTML {
+				if html == nil {
+					html = map[string]bool{}
+				}
+				html[key.value] = true
+			}
+		}
+		p.next()
+	}
+
+	return attrs, html, nil
+}
+
+func (p *parser) addNode(id string, attrs map[string]string, html map[string]bool) {
+	i, ok := p.nodes[id]
+	if !ok {
+		p.nodes[id] = len(p.graph.Nodes)
+		p.graph.Nodes = append(p.graph.Nodes, Node{ID: id, Attrs: copyAttrs(attrs), HTML: maps.Clone(html)})
+		return
+	}
+
+	if len(attrs) == 0 {
+		return
+	}
+
+	if p.graph.Nodes[i].Attrs == nil {
+		p.graph.Nodes[i].Attrs = map[string]string{}
+	}
+	p.graph.Nodes[i].HTML = setAttrs(p.graph.Nodes[i].Attrs, p.graph.Nodes[i].HTML, attrs, html)
+}
+
+// setAttrs copies attributes to target ones and returns target html marks updated by them.
+func setAttrs(target map[string]string, targetHTML map[string]bool, attrs map[string]string, html map[string]bool) map[string]bool {
+	for k, v := range attrs {
+		target[k] = v
+
+		delete(targetHTML, k)
+		if html[k] {
+			if targetHTML == nil {
+				targetHTML = map[string]bool{}
+			}
+			targetHTML[k] = true
+		}
+	}
+
+	return targetHTML
+}
+
+func tokenize(data []byte) ([]token, error) {
+	var tokens []token
+	s := string(data)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\n' || c == '\r' || c == '\t' || c == ' ':
+			i++
+		case c == '#' && (i == 0 || s[i-1] == '\n'):
+			// Preprocessor output line.
+			i = skipLine(s, i)
+		case strings.HasPrefix(s[i:], "//"):
+			i = skipLine(s, i)
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed comment", ErrSyntax)
+			}
+			i += end + 4
+		case strings.HasPrefix(s[i:], "->") || strings.HasPrefix(s[i:], "--"):
+			tokens = append(tokens, token{kind: tokenPunct, value: s[i : i+2]})
+			i += 2
+		case strings.ContainsRune("{}[];,=:", rune(c)):
+			tokens = append(tokens, token{kind: tokenPunct, value: string(c)})
+			i++
+		case c == '"':
+			value, n, err := readQuoted(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenID, value: value})
+			i += n
+		case c == '<':
+			n, err := readHTML(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenHTML, value: s[i+1 : i+n-1]})
+			i += n
+		default:
+			start := i
+			for i < len(s) && isIDChar(rune(s[i])) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("%w: unexpected character '%c'", ErrSyntax, c)
+			}
+			tokens = append(tokens, token{kind: tokenID, value: s[start:i]})
+		}
+	}
+
+	return tokens, nil
+}
+
+func skipLine(s string, i int) int {
+	end := strings.IndexByte(s[i:], '\n')
+	if end < 0 {
+		return len(s)
+	}
+
+	return i + end + 1
+}
+
+// readQuoted reads quoted string and returns its value and length with quotes.
+// Only escaped quotes are unescaped, other escapes like '\l' or '\\' are graphviz ones and kept.
+func readQuoted(s string) (string, int, error) {
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			// Escape takes the next character whole, so the quote after escaped backslash '\\' closes the string.
+			switch s[i+1] {
+			case '"':
+				value.WriteByte('"')
+			case '\n':
+				// Line continuation.
+			default:
+				value.WriteString(s[i : i+2])
+			}
+			i++
+		case s[i] == '"':
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("%w: unclosed quoted string", ErrSyntax)
+}
+
+// readHTML returns length of html-like string with nested angle brackets.
+func readHTML(s string) (int, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("%w: unclosed html string", ErrSyntax)
+}
+
+func isIDChar(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) || r >= 0x80
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// WriteDOT writes graph in graphviz dot language, flat as ParseDOT reads it.
+func (g Graph) WriteDOT(w io.Writer) error {
+	buf := bytes.NewBuffer(nil)
+
+	buf.WriteString("digraph G {\n")
+	if len(g.GraphAttrs) > 0 {
+		fmt.Fprintf(buf, "\tgraph %s;\n", formatAttrs(g.GraphAttrs, g.GraphHTML))
+	}
+	if len(g.NodeAttrs) > 0 {
+		fmt.Fprintf(buf, "\tnode %s;\n", formatAttrs(g.NodeAttrs, g.NodeHTML))
+	}
+	if len(g.EdgeAttrs) > 0 {
+		fmt.Fprintf(buf, "\tedge %s;\n", formatAttrs(g.EdgeAttrs, g.EdgeHTML))
+	}
+
+	for _, node := range g.Nodes {
+		fmt.Fprintf(buf, "\t%s", quote(node.ID))
+		if len(node.Attrs) > 0 {
+			fmt.Fprintf(buf, " %s", formatAttrs(node.Attrs, node.HTML))
+		}
+		buf.WriteString(";\n")
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(buf, "\t%s -> %s", withPort(quote(edge.From), edge.FromPort), withPort(quote(edge.To), edge.ToPort))
+		if len(edge.Attrs) > 0 {
+			fmt.Fprintf(buf, " %s", formatAttrs(edge.Attrs, edge.HTML))
+		}
+		buf.WriteString(";\n")
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// DOT returns graph in graphviz dot language.
+func (g Graph) DOT() []byte {
+	buf := bytes.NewBuffer(nil)
+	// Writing to buffer never fails.
+	_ = g.WriteDOT(buf)
+
+	return buf.Bytes()
+}
+
+// formatAttrs formats attribute list, values marked in html are written in angle brackets.
+func formatAttrs(attrs map[string]string, html map[string]bool) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		value := quote(attrs[k])
+		if html[k] {
+			value = "<" + attrs[k] + ">"
+		}
+		parts = append(parts, k+"="+value)
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func withPort(id string, port string) string {
+	if port == "" {
+		return id
+	}
+
+	return id + ":" + port
+}
+
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
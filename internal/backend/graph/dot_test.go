@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const godaDOT = `digraph G {
+	node [penwidth=1 fontname="Roboto" shape=box style="rounded,filled"];
+	edge [penwidth=1];
+	rankdir=LR;
+	"github.com/username/tmp/cmd/app" [label="app" tooltip="github.com/username/tmp/cmd/app" href="https://pkg.go.dev/github.com/username/tmp/cmd/app" fillcolor="#e8f3f8"];
+	"github.com/username/tmp/internal/worker" [label=<worker<br/><i>internal</i>> tooltip="github.com/username/tmp/internal/worker"];
+	"github.com/username/tmp/pkg/api" [label="api\l\"v1\""];
+	// comment
+	"github.com/username/tmp/cmd/app":e -> "github.com/username/tmp/internal/worker":w [color="#555555"];
+	"github.com/username/tmp/cmd/app" -> "github.com/username/tmp/pkg/api" -> "github.com/username/tmp/internal/worker";
+	subgraph "cluster_ext" {
+		label="external";
+		"golang.org/x/text/language";
+	}
+}
+`
+
+func TestParseDOT(t *testing.T) {
+	// arrange
+	want := Graph{
+		Nodes: []Node{
+			{
+				ID: "github.com/username/tmp/cmd/app",
+				Attrs: map[string]string{
+					"label":     "app",
+					"tooltip":   "github.com/username/tmp/cmd/app",
+					"href":      "https://pkg.go.dev/github.com/username/tmp/cmd/app",
+					"fillcolor": "#e8f3f8",
+				},
+			},
+			{
+				ID: "github.com/username/tmp/internal/worker",
+				Attrs: map[string]string{
+					"label":   "worker<br/><i>internal</i>",
+					"tooltip": "github.com/username/tmp/internal/worker",
+				},
+				HTML: map[string]bool{"label": true},
+			},
+			{
+				ID:    "github.com/username/tmp/pkg/api",
+				Attrs: map[string]string{"label": `api\l"v1"`},
+			},
+			{
+				ID: "golang.org/x/text/language",
+			},
+		},
+		Edges: []Edge{
+			{
+				From:     "github.com/username/tmp/cmd/app",
+				To:       "github.com/username/tmp/internal/worker",
+				Attrs:    map[string]string{"color": "#555555"},
+				FromPort: "e",
+				ToPort:   "w",
+			},
+			{
+				From: "github.com/username/tmp/cmd/app",
+				To:   "github.com/username/tmp/pkg/api",
+			},
+			{
+				From: "github.com/username/tmp/pkg/api",
+				To:   "github.com/username/tmp/internal/worker",
+			},
+		},
+		GraphAttrs: map[string]string{"rankdir": "LR"},
+		NodeAttrs: map[string]string{
+			"penwidth": "1",
+			"fontname": "Roboto",
+			"shape":    "box",
+			"style":    "rounded,filled",
+		},
+		EdgeAttrs: map[string]string{"penwidth": "1"},
+	}
+
+	// act
+	got, err := ParseDOT([]byte(godaDOT))
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestParseDOTEscapes(t *testing.T) {
+	// arrange
+	input := `digraph G {
+	"C:\\" -> "b" [tooltip="<b>" label=<<b>b</b>>];
+	"b" [label="b\"c"];
+}`
+
+	want := `digraph G {
+	"C:\\";
+	"b" [label="b\"c"];
+	"C:\\" -> "b" [label=<<b>b</b>> tooltip="<b>"];
+}
+`
+
+	// act
+	got, err := ParseDOT([]byte(input))
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []Node{{ID: `C:\\`}, {ID: "b", Attrs: map[string]string{"label": `b"c`}}}, got.Nodes)
+	assert.Equal(t, map[string]string{"tooltip": "<b>", "label": "<b>b</b>"}, got.Edges[0].Attrs)
+	assert.Equal(t, map[string]bool{"label": true}, got.Edges[0].HTML, "only html-like values are marked")
+	assert.Equal(t, want, string(got.DOT()))
+
+	relabeled := got.WithEdgeAttrs(map[[2]string]map[string]string{{`C:\\`, "b"}: {"label": "<b>"}})
+	assert.Empty(t, relabeled.Edges[0].HTML, "replaced label is plain")
+	assert.Equal(t, map[string]bool{"label": true}, got.Edges[0].HTML, "source graph isn't modified")
+}
+
+func TestParseDOTErrors(t *testing.T) {
+	for name, input := range map[string]string{
+		"not a graph":      `a -> b`,
+		"unclosed block":   `digraph G { a -> b;`,
+		"unclosed string":  `digraph G { "a -> b; }`,
+		"unclosed attrs":   `digraph G { a [label="a" }`,
+		"no edge target":   `digraph G { a -> ; }`,
+		"unclosed comment": `digraph G { /* a -> b; }`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseDOT([]byte(input))
+			assert.ErrorIs(t, err, ErrSyntax)
+		})
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	// arrange
+	input, err := ParseDOT([]byte(godaDOT))
+	assert.NoError(t, err)
+
+	want := `digraph G {
+	graph [rankdir="LR"];
+	node [fontname="Roboto" penwidth="1" shape="box" style="rounded,filled"];
+	edge [penwidth="1"];
+	"github.com/username/tmp/cmd/app" [fillcolor="#e8f3f8" href="https://pkg.go.dev/github.com/username/tmp/cmd/app" label="app" tooltip="github.com/username/tmp/cmd/app"];
+	"github.com/username/tmp/internal/worker" [label=<worker<br/><i>internal</i>> tooltip="github.com/username/tmp/internal/worker"];
+	"github.com/username/tmp/pkg/api" [label="api\l\"v1\""];
+	"golang.org/x/text/language";
+	"github.com/username/tmp/cmd/app":e -> "github.com/username/tmp/internal/worker":w [color="#555555"];
+	"github.com/username/tmp/cmd/app" -> "github.com/username/tmp/pkg/api";
+	"github.com/username/tmp/pkg/api" -> "github.com/username/tmp/internal/worker";
+}
+`
+
+	// act
+	got := input.DOT()
+
+	// assert
+	assert.Equal(t, want, string(got))
+
+	reparsed, err := ParseDOT(got)
+	assert.NoError(t, err)
+	assert.Equal(t, input, reparsed)
+}
+
+func TestImports(t *testing.T) {
+	// arrange
+	input, err := ParseDOT([]byte(godaDOT))
+	assert.NoError(t, err)
+
+	// act, assert
+	assert.Equal(t,
+		[]string{"github.com/username/tmp/internal/worker", "github.com/username/tmp/pkg/api"},
+		input.Imports("github.com/username/tmp/cmd/app"))
+	assert.Equal(t,
+		[]string{"github.com/username/tmp/cmd/app", "github.com/username/tmp/pkg/api"},
+		input.ImportedBy("github.com/username/tmp/internal/worker"))
+	assert.Empty(t, input.Imports("golang.org/x/text/language"))
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"maps"
+	"sort"
+)
+
+// Node is a package of the dependency graph.
+type Node struct {
+	// ID is the package path.
+	ID    string            `json:"id"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+	// HTML marks attributes having html-like values, e.g. '<b>api</b>' labels, they are written in angle brackets.
+	HTML map[string]bool `json:"-"`
+}
+
+// Edge is an import of package To by package From.
+type Edge struct {
+	From  string            `json:"from"`
+	To    string            `json:"to"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+
+	// Ports of nodes the edge is attached to, e.g. 'e' or 'w'.
+	FromPort string `json:"-"`
+	ToPort   string `json:"-"`
+	// HTML marks attributes having html-like values.
+	HTML map[string]bool `json:"-"`
+}
+
+// Graph is a directed package dependency graph.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+
+	// Default attributes of the graph, its nodes and edges.
+	GraphAttrs map[string]string `json:"-"`
+	NodeAttrs  map[string]string `json:"-"`
+	EdgeAttrs  map[string]string `json:"-"`
+	// Default attributes having html-like values.
+	GraphHTML map[string]bool `json:"-"`
+	NodeHTML  map[string]bool `json:"-"`
+	EdgeHTML  map[string]bool `json:"-"`
+}
+
+// Node returns node by id.
+func (g Graph) Node(id string) (Node, bool) {
+	for _, node := range g.Nodes {
+		if node.ID == id {
+			return node, true
+		}
+	}
+
+	return Node{}, false
+}
+
+// Imports returns sorted ids of nodes the node points to.
+func (g Graph) Imports(id string) []string {
+	var imports []string
+	for _, edge := range g.Edges {
+		if edge.From == id {
+			imports = append(imports, edge.To)
+		}
+	}
+
+	sort.Strings(imports)
+
+	return imports
+}
+
+// ImportedBy returns sorted ids of nodes pointing to the node.
+func (g Graph) ImportedBy(id string) []string {
+	var importedBy []string
+	for _, edge := range g.Edges {
+		if edge.To == id {
+			importedBy = append(importedBy, edge.From)
+		}
+	}
+
+	sort.Strings(importedBy)
+
+	return importedBy
+}
+
+// WithEdgeAttrs returns copy of the graph with attributes added to edges by [from, to] key.
+// Attributes of the source graph aren't modified.
+func (g Graph) WithEdgeAttrs(attrs map[[2]string]map[string]string) Graph {
+	edges := make([]Edge, len(g.Edges))
+	for i, edge := range g.Edges {
+		if added, ok := attrs[[2]string{edge.From, edge.To}]; ok {
+			edge.Attrs = maps.Clone(edge.Attrs)
+			if edge.Attrs == nil {
+				edge.Attrs = make(map[string]string, len(added))
+			}
+
+			maps.Copy(edge.Attrs, added)
+			edge.HTML = plainAttrs(edge.HTML, added)
+		}
+
+		edges[i] = edge
+	}
+
+	g.Edges = edges
+
+	return g
+}
+
+// WithNodeAttrs returns copy of the graph with attributes added to nodes by id.
+// Attributes of the source graph aren't modified.
+func (g Graph) WithNodeAttrs(attrs map[string]map[string]string) Graph {
+	nodes := make([]Node, len(g.Nodes))
+	for i, node := range g.Nodes {
+		if added, ok := attrs[node.ID]; ok {
+			node.Attrs = maps.Clone(node.Attrs)
+			if node.Attrs == nil {
+				node.Attrs = make(map[string]string, len(added))
+			}
+
+			maps.Copy(node.Attrs, added)
+			node.HTML = plainAttrs(node.HTML, added)
+		}
+
+		nodes[i] = node
+	}
+
+	g.Nodes = nodes
+
+	return g
+}
+
+// plainAttrs returns html marks without attributes replaced by plain values, the marks aren't modified.
+func plainAttrs(html map[string]bool, replaced map[string]string) map[string]bool {
+	for k := range replaced {
+		if html[k] {
+			html = maps.Clone(html)
+			for k := range replaced {
+				delete(html, k)
+			}
+
+			return html
+		}
+	}
+
+	return html
+}
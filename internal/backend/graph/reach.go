@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"slices"
+	"sort"
+)
+
+// Reachable returns sorted ids of nodes the node imports directly or transitively.
+// The node itself is included only when it's on a cycle.
+func (g Graph) Reachable(id string) []string {
+	return walk(g.successors(), id)
+}
+
+// Dependents returns sorted ids of nodes importing the node directly or transitively.
+// The node itself is included only when it's on a cycle.
+func (g Graph) Dependents(id string) []string {
+	return walk(g.predecessors(), id)
+}
+
+// ShortestPath returns ids of nodes on the shortest import path from one node to another, both included.
+// Of equally short paths the one through lesser ids is returned. Nil is returned when there's no path.
+func (g Graph) ShortestPath(from string, to string) []string {
+	successors := g.successors()
+
+	// Breadth-first search remembering where nodes are reached from.
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if id == to {
+			path := []string{to}
+			for id != from {
+				id = previous[id]
+				path = append(path, id)
+			}
+			slices.Reverse(path)
+
+			return path
+		}
+
+		for _, next := range successors[id] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil
+}
+
+// successors maps ids to sorted ids of nodes they import.
+func (g Graph) successors() map[string][]string {
+	successors := make(map[string][]string)
+	for _, edge := range g.Edges {
+		successors[edge.From] = append(successors[edge.From], edge.To)
+	}
+
+	for _, ids := range successors {
+		sort.Strings(ids)
+	}
+
+	return successors
+}
+
+// predecessors maps ids to sorted ids of nodes importing them.
+func (g Graph) predecessors() map[string][]string {
+	predecessors := make(map[string][]string)
+	for _, edge := range g.Edges {
+		predecessors[edge.To] = append(predecessors[edge.To], edge.From)
+	}
+
+	for _, ids := range predecessors {
+		sort.Strings(ids)
+	}
+
+	return predecessors
+}
+
+func walk(next map[string][]string, id string) []string {
+	seen := make(map[string]bool)
+	stack := slices.Clone(next[id])
+	for len(stack) > 0 {
+		last := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if seen[last] {
+			continue
+		}
+
+		seen[last] = true
+		stack = append(stack, next[last]...)
+	}
+
+	reached := make([]string, 0, len(seen))
+	for reachedID := range seen {
+		reached = append(reached, reachedID)
+	}
+
+	sort.Strings(reached)
+
+	return reached
+}
@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reachInput() Graph {
+	return Graph{
+		Nodes: []Node{{ID: "app"}, {ID: "api"}, {ID: "db"}, {ID: "log"}, {ID: "cache"}, {ID: "tool"}},
+		Edges: []Edge{
+			{From: "app", To: "api"},
+			{From: "app", To: "cache"},
+			{From: "api", To: "db"},
+			{From: "cache", To: "db"},
+			{From: "db", To: "log"},
+			{From: "tool", To: "log"},
+		},
+	}
+}
+
+func TestReachable(t *testing.T) {
+	input := reachInput()
+
+	assert.Equal(t, []string{"api", "cache", "db", "log"}, input.Reachable("app"))
+	assert.Equal(t, []string{"log"}, input.Reachable("db"))
+	assert.Empty(t, input.Reachable("log"))
+}
+
+func TestDependents(t *testing.T) {
+	input := reachInput()
+
+	assert.Equal(t, []string{"api", "app", "cache", "db", "tool"}, input.Dependents("log"))
+	assert.Empty(t, input.Dependents("app"))
+
+	cycle := Graph{Edges: []Edge{{From: "a", To: "b"}, {From: "b", To: "a"}}}
+	assert.Equal(t, []string{"a", "b"}, cycle.Dependents("a"))
+}
+
+func TestShortestPath(t *testing.T) {
+	input := reachInput()
+
+	assert.Equal(t, []string{"app", "api", "db", "log"}, input.ShortestPath("app", "log"))
+	assert.Equal(t, []string{"db"}, input.ShortestPath("db", "db"))
+	assert.Nil(t, input.ShortestPath("tool", "db"))
+}
@@ -0,0 +1,159 @@
+package graph
+
+import "sort"
+
+// Grouped returns graph of node groups. Edges between nodes of the same group are dropped,
+// parallel edges are merged. Attributes aren't preserved.
+func (g Graph) Grouped(group func(id string) string) Graph {
+	var grouped Graph
+
+	seenNodes := make(map[string]bool)
+	for _, node := range g.Nodes {
+		id := group(node.ID)
+		if !seenNodes[id] {
+			seenNodes[id] = true
+			grouped.Nodes = append(grouped.Nodes, Node{ID: id})
+		}
+	}
+
+	seenEdges := make(map[[2]string]bool)
+	for _, edge := range g.Edges {
+		key := [2]string{group(edge.From), group(edge.To)}
+		if key[0] == key[1] || seenEdges[key] {
+			continue
+		}
+
+		seenEdges[key] = true
+		grouped.Edges = append(grouped.Edges, Edge{From: key[0], To: key[1]})
+	}
+
+	return grouped
+}
+
+// Collapsed returns copy of the graph with nodes of each group merged into a node named by the group.
+// Ungrouped nodes and edges between them keep attributes, edges inside a group are dropped
+// and parallel edges of merged nodes keep attributes of the first one.
+func (g Graph) Collapsed(group func(id string) (string, bool)) Graph {
+	collapsed := Graph{
+		GraphAttrs: g.GraphAttrs,
+		NodeAttrs:  g.NodeAttrs,
+		EdgeAttrs:  g.EdgeAttrs,
+		GraphHTML:  g.GraphHTML,
+		NodeHTML:   g.NodeHTML,
+		EdgeHTML:   g.EdgeHTML,
+	}
+
+	idOf := func(id string) string {
+		if grouped, ok := group(id); ok {
+			return grouped
+		}
+		return id
+	}
+
+	seenNodes := make(map[string]bool)
+	for _, node := range g.Nodes {
+		id := idOf(node.ID)
+		if seenNodes[id] {
+			continue
+		}
+
+		seenNodes[id] = true
+		if id != node.ID {
+			node = Node{ID: id}
+		}
+		collapsed.Nodes = append(collapsed.Nodes, node)
+	}
+
+	seenEdges := make(map[[2]string]bool)
+	for _, edge := range g.Edges {
+		key := [2]string{idOf(edge.From), idOf(edge.To)}
+		if key[0] == key[1] && edge.From != edge.To || seenEdges[key] {
+			continue
+		}
+
+		seenEdges[key] = true
+		edge.From, edge.To = key[0], key[1]
+		collapsed.Edges = append(collapsed.Edges, edge)
+	}
+
+	return collapsed
+}
+
+// StronglyConnected returns components of mutually reachable nodes, i.e. cycles.
+// Single node components are omitted unless the node imports itself.
+// Components and their nodes are sorted.
+func (g Graph) StronglyConnected() [][]string {
+	successors := make(map[string][]string)
+	selfLoops := make(map[string]bool)
+	for _, edge := range g.Edges {
+		successors[edge.From] = append(successors[edge.From], edge.To)
+		if edge.From == edge.To {
+			selfLoops[edge.From] = true
+		}
+	}
+
+	// Tarjan's algorithm.
+	var (
+		index      = make(map[string]int)
+		lowLink    = make(map[string]int)
+		onStack    = make(map[string]bool)
+		stack      []string
+		components [][]string
+	)
+
+	var connect func(id string)
+	connect = func(id string) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range successors[id] {
+			if _, visited := index[next]; !visited {
+				connect(next)
+				lowLink[id] = min(lowLink[id], lowLink[next])
+			} else if onStack[next] {
+				lowLink[id] = min(lowLink[id], index[next])
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == id {
+				break
+			}
+		}
+
+		if len(component) > 1 || selfLoops[id] {
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if _, visited := index[node.ID]; !visited {
+			connect(node.ID)
+		}
+	}
+
+	// Edges may refer to nodes which aren't declared.
+	for _, edge := range g.Edges {
+		if _, visited := index[edge.From]; !visited {
+			connect(edge.From)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0] < components[j][0]
+	})
+
+	return components
+}
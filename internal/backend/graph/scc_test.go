@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrouped(t *testing.T) {
+	// arrange
+	input := Graph{
+		Nodes: []Node{{ID: "a/x"}, {ID: "a/y"}, {ID: "b/z"}},
+		Edges: []Edge{
+			{From: "a/x", To: "a/y"},
+			{From: "a/x", To: "b/z"},
+			{From: "a/y", To: "b/z"},
+			{From: "b/z", To: "a/y"},
+		},
+	}
+	want := Graph{
+		Nodes: []Node{{ID: "a"}, {ID: "b"}},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "b", To: "a"}},
+	}
+
+	// act
+	got := input.Grouped(func(id string) string {
+		group, _, _ := strings.Cut(id, "/")
+		return group
+	})
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestCollapsed(t *testing.T) {
+	// arrange
+	red := map[string]string{"color": "red"}
+	input := Graph{
+		Nodes: []Node{{ID: "a/x", Attrs: red}, {ID: "a/y"}, {ID: "b", Attrs: red}},
+		Edges: []Edge{
+			{From: "a/x", To: "a/y"},
+			{From: "a/x", To: "b", Attrs: red, FromPort: "e", ToPort: "w"},
+			{From: "a/y", To: "b"},
+			{From: "b", To: "b"},
+		},
+	}
+	want := Graph{
+		Nodes: []Node{{ID: "a"}, {ID: "b", Attrs: red}},
+		Edges: []Edge{
+			{From: "a", To: "b", Attrs: red, FromPort: "e", ToPort: "w"},
+			{From: "b", To: "b"},
+		},
+	}
+
+	// act
+	got := input.Collapsed(func(id string) (string, bool) {
+		group, _, ok := strings.Cut(id, "/")
+		return group, ok
+	})
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestStronglyConnected(t *testing.T) {
+	// arrange
+	input := Graph{
+		Nodes: []Node{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}, {ID: "f"}},
+		Edges: []Edge{
+			{From: "a", To: "b"},
+			{From: "b", To: "c"},
+			{From: "c", To: "a"},
+			{From: "c", To: "d"},
+			{From: "e", To: "f"},
+			{From: "f", To: "e"},
+			{From: "d", To: "d"},
+		},
+	}
+
+	// act
+	got := input.StronglyConnected()
+
+	// assert
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d"}, {"e", "f"}}, got)
+}
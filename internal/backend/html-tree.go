@@ -4,35 +4,53 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
-	"text/template"
+
+	"golang.org/x/mod/modfile"
 
 	"github.com/alexuserid/go-codevis/internal/backend/tree"
 )
 
 type DirNode struct {
-	Name          string
-	Path          string
-	Children      []DirNode
-	IsGoPackage   bool
-	IsRoot        bool
-	TagTreePrefix string
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Children []DirNode `json:"children,omitempty"`
+	// Files are all files of the directory, not only go ones.
+	Files       []FileNode `json:"files,omitempty"`
+	IsGoPackage bool       `json:"isGoPackage"`
+	// TestOnly is set for packages having only test files.
+	TestOnly bool `json:"testOnly,omitempty"`
+	IsRoot   bool `json:"isRoot,omitempty"`
+	// FilesOnly is set for directories without go files down the tree, e.g. proto or testdata ones.
+	FilesOnly bool `json:"filesOnly,omitempty"`
 }
 
-type HTMLNode struct {
-	ID        string
-	Text      string
-	TagPrefix string
-	Class     string
+// FileNode is a file of the tree, its path is relative to the tree root like the source endpoint expects.
+type FileNode struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
 }
 
-const (
-	connectNodesPrefix = "│   "
-	middleNodePrefix   = "├─ "
-	lastNodePrefix     = "└─ "
-	lastParentPrefix   = "   "
-)
+// HTMLNode is an entry of the rendered tree: a directory with nested entries or a file leaf.
+type HTMLNode struct {
+	ID    string
+	Text  string
+	Class string
+	// Dir is the slash separated directory path relative to the module root, empty for files.
+	Dir string
+	// Columns is additional info shown after the entry, e.g. package metrics.
+	Columns string
+	// Size is the formatted size of files.
+	Size     string
+	Children []HTMLNode
+	// FilesOnly directories are shown with files only.
+	FilesOnly bool
+}
 
 var ErrNoModuleDirective = errors.New("didn't find 'module' directive in 'go.mod' file")
 
@@ -40,15 +58,33 @@ var ErrNoModuleDirective = errors.New("didn't find 'module' directive in 'go.mod
 // - when packages name are different from directories name.
 // - other?
 
-func TreeToHTML(inputTree tree.Node) ([]byte, error) {
+// TreeToHTML renders tree of go packages. Columns are shown after entries by entry id,
+// which is the tree root absolute path or a directory path relative to it.
+func TreeToHTML(inputTree tree.Node, columns map[string]string) ([]byte, error) {
+	packagesTree, err := PackagesTree(inputTree)
+	if err != nil {
+		return nil, fmt.Errorf("packages tree: %w", err)
+	}
+
+	htmlData, err := htmlTree(toHTMLNode(packagesTree, columns))
+	if err != nil {
+		return nil, fmt.Errorf("build html tree: %w", err)
+	}
+
+	return htmlData, nil
+}
+
+// PackagesTree returns sorted tree of directories containing go files.
+func PackagesTree(inputTree tree.Node) (DirNode, error) {
 	modulePath, err := getModulePath(inputTree)
 	if err != nil {
-		return nil, fmt.Errorf("get module path: %w", err)
+		return DirNode{}, fmt.Errorf("get module path: %w", err)
 	}
 
 	packagesTree, hasGoFiles := goDirectories(inputTree)
 
 	packagesTree.IsRoot = true
+	packagesTree.FilesOnly = false
 	packagesTree.Name = modulePath
 	packagesTree.Path = modulePath
 
@@ -58,49 +94,58 @@ func TreeToHTML(inputTree tree.Node) ([]byte, error) {
 
 	sortAlphabetic(packagesTree)
 
-	writeTagPrefixes(packagesTree, "")
+	return packagesTree, nil
+}
 
-	list := treeToList(inputTree.Path, packagesTree, []HTMLNode{})
+func getModulePath(inputTree tree.Node) (string, error) {
+	return inputTree.AbsPath, nil
+}
 
-	htmlData, err := htmlTree(list)
+// readModulePath returns module import path from 'go.mod' in the tree root.
+func readModulePath(inputTree tree.Node) (string, error) {
+	data, err := os.ReadFile(filepath.Join(inputTree.Path, "go.mod"))
 	if err != nil {
-		return nil, fmt.Errorf("build html tree: %w", err)
+		return "", fmt.Errorf("read 'go.mod': %w", err)
 	}
 
-	return htmlData, nil
-}
+	modulePath := modfile.ModulePath(data)
+	if modulePath == "" {
+		return "", ErrNoModuleDirective
+	}
 
-func getModulePath(inputTree tree.Node) (string, error) {
-	return inputTree.AbsPath, nil
+	return modulePath, nil
 }
 
+// goDirectories returns directories of the tree with their files, true is returned when there are go files in it.
+// Directories without go files down the tree are marked FilesOnly, empty ones are omitted.
 func goDirectories(inputTree tree.Node) (DirNode, bool) {
 	filtered := DirNode{
 		Name: inputTree.Name,
 		Path: inputTree.Path,
 	}
 
+	hasGo := hasGoFiles(inputTree.Children)
 	if hasDirs(inputTree.Children) {
 		for _, child := range inputTree.Children {
 			filteredChild, ok := goDirectories(child)
-			if !ok {
+			if !ok && len(filteredChild.Files) == 0 && len(filteredChild.Children) == 0 {
 				continue
 			}
 
+			hasGo = hasGo || ok
 			filtered.Children = append(filtered.Children, filteredChild)
 		}
 	}
 
 	if hasGoFiles(inputTree.Children) {
 		filtered.IsGoPackage = true
-		return filtered, true
+		filtered.TestOnly = onlyTestFiles(inputTree.Children)
 	}
 
-	if len(filtered.Children) > 0 {
-		return filtered, true
-	}
+	filtered.Files = dirFiles(inputTree.Children)
+	filtered.FilesOnly = !hasGo
 
-	return filtered, false
+	return filtered, hasGo
 }
 
 func sortAlphabetic(inputTree DirNode) {
@@ -113,67 +158,62 @@ func sortAlphabetic(inputTree DirNode) {
 	}
 }
 
-func writeTagPrefixes(inputTree DirNode, initialPrefix string) {
-	lastChild := len(inputTree.Children) - 1
-	for i := range inputTree.Children {
-		isLastChild := i == lastChild
-
-		var prefix string
-		if isLastChild {
-			prefix = initialPrefix + lastNodePrefix
-		} else {
-			prefix = initialPrefix + middleNodePrefix
-		}
-
-		inputTree.Children[i].TagTreePrefix = prefix
-
-		childInintialPrefix := initialPrefix
-		if isLastChild {
-			childInintialPrefix += lastParentPrefix
-		} else {
-			childInintialPrefix += connectNodesPrefix
-		}
+// toHTMLNode converts directory with its subdirectories and files to tree entries.
+// Subdirectories go before files.
+func toHTMLNode(node DirNode, columns map[string]string) HTMLNode {
+	dir := filepath.ToSlash(node.Path)
+	if node.IsRoot {
+		dir = "."
+	}
 
-		writeTagPrefixes(inputTree.Children[i], childInintialPrefix)
+	htmlNode := HTMLNode{
+		ID:        node.Path,
+		Text:      node.Name,
+		Class:     htmlNodeClass(node),
+		Dir:       dir,
+		Columns:   columns[node.Path],
+		FilesOnly: node.FilesOnly,
 	}
-}
 
-func treeToList(rootPath string, inputTree DirNode, list []HTMLNode) []HTMLNode {
-	if inputTree.IsRoot {
-		list = append(list, HTMLNode{
-			ID:        inputTree.Path,
-			Text:      inputTree.Name,
-			TagPrefix: inputTree.TagTreePrefix,
-			Class:     htmlNodeClass(inputTree),
-		})
+	for _, child := range node.Children {
+		htmlNode.Children = append(htmlNode.Children, toHTMLNode(child, columns))
 	}
 
-	for _, child := range inputTree.Children {
-		list = append(list, HTMLNode{
-			ID:        child.Path,
-			Text:      child.Name,
-			TagPrefix: child.TagTreePrefix,
-			Class:     htmlNodeClass(child),
+	for _, file := range node.Files {
+		htmlNode.Children = append(htmlNode.Children, HTMLNode{
+			ID:    file.Path,
+			Text:  file.Name,
+			Class: fileClass(file),
+			Size:  formatSize(file.Size),
 		})
-
-		list = treeToList(rootPath, child, list)
 	}
 
-	return list
+	return htmlNode
 }
 
-func htmlTree(htmlNodes []HTMLNode) ([]byte, error) {
-	htmlTemplate := `
-	{{range .}}{{.TagPrefix}}<span class="{{.Class}} tree-entry" id="{{.ID}}">{{.Text}}</span><br>
-	{{end}}`
-	parsedTemplate, err := template.New("tree").Parse(htmlTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("parse tree template: %w", err)
-	}
-
+var treeTemplate = template.Must(template.New("tree").Parse(`
+{{- define "node" -}}
+<li class="{{if .Dir}}tree-dir{{if .FilesOnly}} tree-files-only{{end}}{{else}}tree-leaf{{end}}"{{if .Dir}} data-dir="{{.Dir}}"{{end}}>
+{{- if .Dir}}<span class="tree-toggle"></span>{{end -}}
+<span class="{{.Class}} tree-entry" id="{{.ID}}">{{.Text}}</span>
+{{- if .Columns}} <span class="tree-columns">{{.Columns}}</span>{{end}}
+{{- if .Size}} <span class="tree-size">{{.Size}}</span>{{end}}
+{{- if .Children}}
+<ul>
+{{range .Children}}{{template "node" .}}{{end -}}
+</ul>
+{{- end -}}
+</li>
+{{end -}}
+<ul class="tree hide-files">
+{{template "node" .}}</ul>
+`))
+
+// htmlTree renders nested lists, directories can be folded by toggles on the page.
+func htmlTree(root HTMLNode) ([]byte, error) {
 	buf := bytes.NewBuffer([]byte{})
 
-	if err = parsedTemplate.Execute(buf, htmlNodes); err != nil {
+	if err := treeTemplate.Execute(buf, root); err != nil {
 		return nil, fmt.Errorf("execute template: %w", err)
 	}
 
@@ -199,14 +239,63 @@ func hasGoFiles(children []tree.Node) bool {
 	return false
 }
 
+func onlyTestFiles(children []tree.Node) bool {
+	for _, child := range children {
+		if strings.HasSuffix(child.Name, ".go") && !strings.HasSuffix(child.Name, "_test.go") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func dirFiles(children []tree.Node) []FileNode {
+	var files []FileNode
+	for _, child := range children {
+		if !child.IsDir {
+			files = append(files, FileNode{Name: child.Name, Path: child.Path, Size: child.Size})
+		}
+	}
+
+	return files
+}
+
+// formatSize returns size in bytes or binary units, e.g. '1.5 KiB'.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
 func htmlNodeClass(node DirNode) string {
 	if node.IsRoot {
 		return "root"
 	}
 
+	if node.TestOnly {
+		return "gopkg testpkg"
+	}
+
 	if node.IsGoPackage {
 		return "gopkg"
 	}
 
 	return "nopkg"
 }
+
+func fileClass(file FileNode) string {
+	if strings.HasSuffix(file.Name, ".go") {
+		return "gofile"
+	}
+
+	return "file"
+}
@@ -1,7 +1,6 @@
 package backend
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/alexuserid/go-codevis/internal/backend/tree"
@@ -16,7 +15,25 @@ func TestGoDirectories(t *testing.T) {
 		want := DirNode{
 			Name: ".",
 			Path: ".",
+			Files: []FileNode{
+				{Name: "README.md", Path: "README.md"},
+				{Name: "go.mod", Path: "go.mod"},
+				{Name: "go.mod", Path: "go.sum"},
+			},
 			Children: []DirNode{
+				{
+					Name:      "api",
+					Path:      "api",
+					FilesOnly: true,
+					Children: []DirNode{
+						{
+							Name:      "v1",
+							Path:      "api/v1",
+							FilesOnly: true,
+							Files:     []FileNode{{Name: "my-type.proto", Path: "api/v1/my-type.proto"}},
+						},
+					},
+				},
 				{
 					Name: "cmd",
 					Path: "cmd",
@@ -25,6 +42,7 @@ func TestGoDirectories(t *testing.T) {
 							Name:        "app",
 							Path:        "cmd/app",
 							IsGoPackage: true,
+							Files:       []FileNode{{Name: "main.go", Path: "cmd/app/main.go"}},
 						},
 					},
 				},
@@ -32,16 +50,22 @@ func TestGoDirectories(t *testing.T) {
 					Name:        "internal",
 					Path:        "internal",
 					IsGoPackage: true,
+					Files:       []FileNode{{Name: "internal.go", Path: "internal/internal.go"}},
 					Children: []DirNode{
 						{
 							Name:        "featureflag",
 							Path:        "internal/featureflag",
 							IsGoPackage: true,
+							Files: []FileNode{
+								{Name: "featureflag.go", Path: "internal/featureflag/featureglag.go"},
+								{Name: "featureflag_test.go", Path: "internal/featureflag/featureglag_test.go"},
+							},
 						},
 						{
 							Name:        "worker",
 							Path:        "internal/worker",
 							IsGoPackage: true,
+							Files:       []FileNode{{Name: "worker.go", Path: "internal/featureflag/worker.go"}},
 						},
 					},
 				},
@@ -53,6 +77,7 @@ func TestGoDirectories(t *testing.T) {
 							Name:        "api",
 							Path:        "pkg/api",
 							IsGoPackage: true,
+							Files:       []FileNode{{Name: "grpc.go", Path: "pkg/api/grpc.go"}},
 						},
 					},
 				},
@@ -119,9 +144,31 @@ func TestGoDirectories(t *testing.T) {
 			},
 		}
 
+		// Directories without go files are kept for files, empty ones are omitted.
 		want := DirNode{
-			Name: ".",
-			Path: ".",
+			Name:      ".",
+			Path:      ".",
+			FilesOnly: true,
+			Files: []FileNode{
+				{Name: "README.md", Path: "README.md"},
+				{Name: "go.mod", Path: "go.mod"},
+				{Name: "go.mod", Path: "go.sum"},
+			},
+			Children: []DirNode{
+				{
+					Name:      "api",
+					Path:      "api",
+					FilesOnly: true,
+					Children: []DirNode{
+						{
+							Name:      "v1",
+							Path:      "api/v1",
+							FilesOnly: true,
+							Files:     []FileNode{{Name: "my-type.proto", Path: "api/v1/my-type.proto"}},
+						},
+					},
+				},
+			},
 		}
 
 		// act
@@ -141,8 +188,9 @@ func TestGoDirectories(t *testing.T) {
 		}
 
 		want := DirNode{
-			Name: ".",
-			Path: ".",
+			Name:      ".",
+			Path:      ".",
+			FilesOnly: true,
 		}
 
 		// act
@@ -226,319 +274,123 @@ func TestSortAlphabetic(t *testing.T) {
 	})
 }
 
-func TestWriteTagPrefixes(t *testing.T) {
+func TestToHTMLNode(t *testing.T) {
 	// arrange
 	input := DirNode{
-		Path: ".",
+		Name:   "example.com/app",
+		Path:   "/src/app",
+		IsRoot: true,
+		Files:  []FileNode{{Name: "go.mod", Path: "go.mod", Size: 30}},
 		Children: []DirNode{
 			{
-				Path: "cmd",
-				Children: []DirNode{
-					{
-						Path: "cmd/app",
-					},
-				},
+				Name:        "worker",
+				Path:        "worker",
+				IsGoPackage: true,
+				Files:       []FileNode{{Name: "worker.go", Path: "worker/worker.go", Size: 2048}},
 			},
 			{
-				Path: "internal",
-				Children: []DirNode{
-					{
-						Path: "internal/worker",
-					},
-					{
-						Path: "internal/app",
-						Children: []DirNode{
-							{
-								Path: "internal/app/featureflag",
-							},
-						},
-					},
-				},
+				Name:        "e2e",
+				Path:        "e2e",
+				IsGoPackage: true,
+				TestOnly:    true,
 			},
 			{
-				Path: "pkg",
-				Children: []DirNode{
-					{
-						Path: "pkg/grpc",
-					},
-				},
+				Name:      "proto",
+				Path:      "proto",
+				FilesOnly: true,
+				Files:     []FileNode{{Name: "worker.proto", Path: "proto/worker.proto", Size: 100}},
 			},
 		},
 	}
-
-	want := DirNode{
-		Path: ".",
-		Children: []DirNode{
-			{
-				Path:          "cmd",
-				TagTreePrefix: "├─ ",
-				Children: []DirNode{
-					{
-						Path:          "cmd/app",
-						TagTreePrefix: "│   └─ ",
-					},
-				},
-			},
+	columns := map[string]string{"worker": "10 loc"}
+
+	want := HTMLNode{
+		ID:    "/src/app",
+		Text:  "example.com/app",
+		Class: "root",
+		Dir:   ".",
+		Children: []HTMLNode{
 			{
-				Path:          "internal",
-				TagTreePrefix: "├─ ",
-				Children: []DirNode{
-					{
-						Path:          "internal/worker",
-						TagTreePrefix: "│   ├─ ",
-					},
-					{
-						Path:          "internal/app",
-						TagTreePrefix: "│   └─ ",
-						Children: []DirNode{
-							{
-								Path:          "internal/app/featureflag",
-								TagTreePrefix: "│      └─ ",
-							},
-						},
-					},
+				ID:      "worker",
+				Text:    "worker",
+				Class:   "gopkg",
+				Dir:     "worker",
+				Columns: "10 loc",
+				Children: []HTMLNode{
+					{ID: "worker/worker.go", Text: "worker.go", Class: "gofile", Size: "2.0 KiB"},
 				},
 			},
+			{ID: "e2e", Text: "e2e", Class: "gopkg testpkg", Dir: "e2e"},
 			{
-				Path:          "pkg",
-				TagTreePrefix: "└─ ",
-				Children: []DirNode{
-					{
-						Path:          "pkg/grpc",
-						TagTreePrefix: "   └─ ",
-					},
+				ID:        "proto",
+				Text:      "proto",
+				Class:     "nopkg",
+				Dir:       "proto",
+				FilesOnly: true,
+				Children: []HTMLNode{
+					{ID: "proto/worker.proto", Text: "worker.proto", Class: "file", Size: "100 B"},
 				},
 			},
+			{ID: "go.mod", Text: "go.mod", Class: "file", Size: "30 B"},
 		},
 	}
 
 	// act
-	writeTagPrefixes(input, "")
-
-	fmt.Println(input.TagTreePrefix, input.Path)
-	printChildren(input)
-
-	fmt.Println(want.TagTreePrefix, want.Path)
-	printChildren(want)
+	got := toHTMLNode(input, columns)
 
 	// assert
-	assert.Equal(t, want, input)
-}
-
-func printChildren(node DirNode) {
-	for _, child := range node.Children {
-		fmt.Println(child.TagTreePrefix, child.Path)
-
-		printChildren(child)
-	}
+	assert.Equal(t, want, got)
 }
 
-func TestTreeToList(t *testing.T) {
+func TestHTMLTree(t *testing.T) {
 	// arrange
-	input := DirNode{
-		Path:   "github.com/username/tmp",
-		Name:   "github.com/username/tmp",
-		IsRoot: true,
-		Children: []DirNode{
+	input := HTMLNode{
+		ID:    "/src/app",
+		Text:  "example.com/app",
+		Class: "root",
+		Dir:   ".",
+		Children: []HTMLNode{
 			{
-				Name:          "cmd",
-				Path:          "cmd",
-				TagTreePrefix: "├─ ",
-				Children: []DirNode{
-					{
-						Name:          "app",
-						Path:          "cmd/app",
-						TagTreePrefix: "│   └─ ",
-						IsGoPackage:   true,
-					},
-				},
-			},
-			{
-				Name:          "internal",
-				Path:          "internal",
-				IsGoPackage:   true,
-				TagTreePrefix: "├─ ",
-				Children: []DirNode{
-					{
-						Name:          "worker",
-						Path:          "internal/worker",
-						IsGoPackage:   true,
-						TagTreePrefix: "│   ├─ ",
-					},
-					{
-						Name:          "app",
-						Path:          "internal/app",
-						TagTreePrefix: "│   └─ ",
-						Children: []DirNode{
-							{
-								Name:          "featureflag",
-								Path:          "internal/app/featureflag",
-								IsGoPackage:   true,
-								TagTreePrefix: "│      └─ ",
-							},
-						},
-					},
-				},
-			},
-			{
-				Name:          "pkg",
-				Path:          "pkg",
-				TagTreePrefix: "└─ ",
-				Children: []DirNode{
-					{
-						Name:          "grpc",
-						Path:          "pkg/grpc",
-						IsGoPackage:   true,
-						TagTreePrefix: "   └─ ",
-					},
+				ID:      "worker",
+				Text:    "worker",
+				Class:   "gopkg",
+				Dir:     "worker",
+				Columns: "10 loc",
+				Children: []HTMLNode{
+					{ID: "worker/worker.go", Text: "worker.go", Class: "gofile", Size: "2.0 KiB"},
 				},
 			},
+			{ID: "proto", Text: "proto", Class: "nopkg", Dir: "proto", FilesOnly: true},
 		},
 	}
 
-	want := []HTMLNode{
-		{
-			ID:        "github.com/username/tmp",
-			Text:      "github.com/username/tmp",
-			TagPrefix: "",
-			Class:     "root",
-		},
-		{
-			ID:        "cmd",
-			Text:      "cmd",
-			TagPrefix: "├─ ",
-			Class:     "nopkg",
-		},
-		{
-			ID:        "cmd/app",
-			Text:      "app",
-			TagPrefix: "│   └─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "internal",
-			Text:      "internal",
-			TagPrefix: "├─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "internal/worker",
-			Text:      "worker",
-			TagPrefix: "│   ├─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "internal/app",
-			Text:      "app",
-			TagPrefix: "│   └─ ",
-			Class:     "nopkg",
-		},
-		{
-			ID:        "internal/app/featureflag",
-			Text:      "featureflag",
-			TagPrefix: "│      └─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "pkg",
-			Text:      "pkg",
-			TagPrefix: "└─ ",
-			Class:     "nopkg",
-		},
-		{
-			ID:        "pkg/grpc",
-			Text:      "grpc",
-			TagPrefix: "   └─ ",
-			Class:     "gopkg",
-		},
-	}
-
-	// act
-	got := treeToList(input.Path, input, []HTMLNode{})
-
-	// assert
-	assert.Equal(t, want, got)
-}
-
-func TestHTMLTree(t *testing.T) {
-	// arrange
-	input := []HTMLNode{
-		{
-			ID:        "gitpub.com/username/tmp",
-			Text:      "gitpub.com/username/tmp",
-			TagPrefix: "",
-			Class:     "root",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/cmd",
-			Text:      "cmd",
-			TagPrefix: "├─ ",
-			Class:     "nopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/cmd/app",
-			Text:      "app",
-			TagPrefix: "│   └─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/internal",
-			Text:      "internal",
-			TagPrefix: "├─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/internal/worker",
-			Text:      "worker",
-			TagPrefix: "│   ├─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/internal/app",
-			Text:      "app",
-			TagPrefix: "│   └─ ",
-			Class:     "nopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/internal/app/featureflag",
-			Text:      "featureflag",
-			TagPrefix: "│      └─ ",
-			Class:     "gopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/pkg",
-			Text:      "pkg",
-			TagPrefix: "└─ ",
-			Class:     "nopkg",
-		},
-		{
-			ID:        "gitpub.com/username/tmp/pkg/grpc",
-			Text:      "grpc",
-			TagPrefix: "    └─ ",
-			Class:     "gopkg",
-		},
-	}
-
-	want := `
-	<span class="root tree-entry" id="gitpub.com/username/tmp">gitpub.com/username/tmp</span><br>
-	├─ <span class="nopkg tree-entry" id="gitpub.com/username/tmp/cmd">cmd</span><br>
-	│   └─ <span class="gopkg tree-entry" id="gitpub.com/username/tmp/cmd/app">app</span><br>
-	├─ <span class="gopkg tree-entry" id="gitpub.com/username/tmp/internal">internal</span><br>
-	│   ├─ <span class="gopkg tree-entry" id="gitpub.com/username/tmp/internal/worker">worker</span><br>
-	│   └─ <span class="nopkg tree-entry" id="gitpub.com/username/tmp/internal/app">app</span><br>
-	│      └─ <span class="gopkg tree-entry" id="gitpub.com/username/tmp/internal/app/featureflag">featureflag</span><br>
-	└─ <span class="nopkg tree-entry" id="gitpub.com/username/tmp/pkg">pkg</span><br>
-	    └─ <span class="gopkg tree-entry" id="gitpub.com/username/tmp/pkg/grpc">grpc</span><br>
-	`
+	want := `<ul class="tree hide-files">
+<li class="tree-dir" data-dir="."><span class="tree-toggle"></span><span class="root tree-entry" id="/src/app">example.com/app</span>
+<ul>
+<li class="tree-dir" data-dir="worker"><span class="tree-toggle"></span><span class="gopkg tree-entry" id="worker">worker</span> <span class="tree-columns">10 loc</span>
+<ul>
+<li class="tree-leaf"><span class="gofile tree-entry" id="worker/worker.go">worker.go</span> <span class="tree-size">2.0 KiB</span></li>
+</ul></li>
+<li class="tree-dir tree-files-only" data-dir="proto"><span class="tree-toggle"></span><span class="nopkg tree-entry" id="proto">proto</span></li>
+</ul></li>
+</ul>
+`
 
 	// act
 	got, err := htmlTree(input)
-	assert.NoError(t, err)
 
 	// assert
+	assert.NoError(t, err)
 	assert.Equal(t, want, string(got))
 }
 
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "0 B", formatSize(0))
+	assert.Equal(t, "1023 B", formatSize(1023))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "3.0 MiB", formatSize(3<<20))
+}
+
 func testData() tree.Node {
 	/*
 		gitpub.com/username/tmp
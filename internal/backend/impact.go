@@ -0,0 +1,274 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+// defaultImpactBase is the revision changes are taken since, i.e. uncommitted ones.
+const defaultImpactBase = "HEAD"
+
+// impactSummary lists packages affected by changed files.
+type impactSummary struct {
+	// Changed are packages of changed files.
+	Changed []string `json:"changed"`
+	// Dependents are packages importing changed ones directly or transitively, or having tests importing them.
+	Dependents []string `json:"dependents"`
+	// Tests are changed and dependent packages having test files.
+	Tests []string `json:"tests"`
+	// Unmapped are changed files outside packages of the graph, e.g. go files of removed packages.
+	Unmapped []string `json:"unmapped"`
+}
+
+// Impact prints changed and dependent packages, one per line, e.g. for 'go test' in CI.
+// Files are relative to the working directory, no files means files changed since base revision.
+// Only packages having tests are printed with testsOnly.
+func Impact(cfg Config, base string, files []string, testsOnly bool, w io.Writer) error {
+	ctx := context.Background()
+
+	// Resolve before changing working directory.
+	absFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return fmt.Errorf("absolute file path: %w", err)
+		}
+		absFiles = append(absFiles, absFile)
+	}
+
+	if err := enterModuleDir(&cfg); err != nil {
+		return err
+	}
+
+	dirTree, err := tree.BuildTree(".", cfg.WithHidden)
+	if err != nil {
+		return fmt.Errorf("build tree: %w", err)
+	}
+
+	modulePath, err := readModulePath(dirTree)
+	if err != nil {
+		return fmt.Errorf("read module path: %w", err)
+	}
+
+	var changed []string
+	if len(files) > 0 {
+		changed, err = moduleFiles(absFiles, dirTree.AbsPath)
+	} else {
+		changed, err = changedFiles(ctx, base)
+	}
+	if err != nil {
+		return fmt.Errorf("changed files: %w", err)
+	}
+
+	deps, _, err := loadDepsGraph(ctx, cfg.Expr)
+	if err != nil {
+		return fmt.Errorf("load dependency graph: %w", err)
+	}
+
+	// Empty graph would show nothing is affected.
+	if len(deps.Nodes) == 0 {
+		return errors.New("package graph is empty")
+	}
+
+	// Goda graph has no imports of tests, tests importing changed packages are affected too.
+	_, testImports, err := loadTestDeps(ctx, modulePath, "./...")
+	if err != nil {
+		return fmt.Errorf("load imports of tests: %w", err)
+	}
+
+	summary := computeImpact(deps, testImports, dirTree, modulePath, changed)
+	for _, file := range summary.Unmapped {
+		log.Printf("changed file '%s' isn't in a package of the graph", file)
+	}
+
+	impacted := append(append([]string{}, summary.Changed...), summary.Dependents...)
+	if testsOnly {
+		impacted = summary.Tests
+	}
+
+	sort.Strings(impacted)
+	for _, pkg := range impacted {
+		fmt.Fprintln(w, pkg)
+	}
+
+	return nil
+}
+
+// moduleFiles makes absolute files relative to the module directory.
+func moduleFiles(absFiles []string, moduleDir string) ([]string, error) {
+	files := make([]string, 0, len(absFiles))
+	for _, absFile := range absFiles {
+		file, err := filepath.Rel(moduleDir, absFile)
+		if err != nil || strings.HasPrefix(file, "..") {
+			return nil, fmt.Errorf("file '%s' is outside of module '%s'", absFile, moduleDir)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// changedFiles lists files of the module directory changed since the branch forked from base revision,
+// uncommitted and untracked ones included. Empty base means uncommitted changes.
+func changedFiles(ctx context.Context, base string) ([]string, error) {
+	if base == "" {
+		base = defaultImpactBase
+	}
+
+	// 'base...HEAD' diffs with the merge base, so commits made to base after the fork aren't counted.
+	// Paths are relative to the working directory with '--relative', so to the module root.
+	committed, err := git(ctx, "diff", "--name-only", "--relative", base+"...HEAD", "--")
+	if err != nil {
+		return nil, fmt.Errorf("diff with '%s': %w", base, err)
+	}
+
+	uncommitted, err := git(ctx, "diff", "--name-only", "--relative", "HEAD", "--")
+	if err != nil {
+		return nil, fmt.Errorf("diff working tree: %w", err)
+	}
+
+	untracked, err := git(ctx, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("list untracked files: %w", err)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(committed+"\n"+uncommitted+"\n"+untracked, "\n") {
+		if line != "" && !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
+// computeImpact maps files relative to the module root to packages of their directories and finds their dependents.
+// Other files than go ones belong to the closest package above as they may be embedded or test data.
+// Changed 'go.mod' or 'go.sum' affects every package of the module.
+// Packages with tests importing affected ones by testImports, see loadTestDeps, are dependents too,
+// but their importers aren't: tests aren't imported.
+func computeImpact(deps graph.Graph, testImports map[[2]string]bool, dirTree tree.Node, modulePath string, files []string) impactSummary {
+	summary := impactSummary{
+		Changed:    []string{},
+		Dependents: []string{},
+		Tests:      []string{},
+		Unmapped:   []string{},
+	}
+
+	changed := make(map[string]bool)
+	for _, file := range files {
+		file = path.Clean(filepath.ToSlash(file))
+		if file == "go.mod" || file == "go.sum" {
+			for _, node := range deps.Nodes {
+				if packageDir(node.ID, modulePath) != "" {
+					changed[node.ID] = true
+				}
+			}
+			continue
+		}
+
+		pkgPath, ok := filePackage(deps, modulePath, file)
+		if !ok {
+			summary.Unmapped = append(summary.Unmapped, file)
+			continue
+		}
+
+		changed[pkgPath] = true
+	}
+
+	dependents := make(map[string]bool)
+	for pkgPath := range changed {
+		summary.Changed = append(summary.Changed, pkgPath)
+		for _, dependent := range deps.Dependents(pkgPath) {
+			if !changed[dependent] && !dependents[dependent] {
+				dependents[dependent] = true
+				summary.Dependents = append(summary.Dependents, dependent)
+			}
+		}
+	}
+
+	// Importers of test dependents aren't followed, so they're collected apart.
+	testDependents := make(map[string]bool)
+	for key := range testImports {
+		from, to := key[0], key[1]
+		if (changed[to] || dependents[to]) && !changed[from] && !dependents[from] && !testDependents[from] {
+			testDependents[from] = true
+			summary.Dependents = append(summary.Dependents, from)
+		}
+	}
+
+	testDirs := testDirectories(dirTree)
+	for _, pkgPath := range append(append([]string{}, summary.Changed...), summary.Dependents...) {
+		if testDirs[packageDir(pkgPath, modulePath)] {
+			summary.Tests = append(summary.Tests, pkgPath)
+		}
+	}
+
+	sort.Strings(summary.Changed)
+	sort.Strings(summary.Dependents)
+	sort.Strings(summary.Tests)
+	sort.Strings(summary.Unmapped)
+
+	return summary
+}
+
+// filePackage returns package of the graph the file belongs to.
+func filePackage(deps graph.Graph, modulePath string, file string) (string, bool) {
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		pkgPath := modulePath
+		if dir != "." {
+			pkgPath += "/" + dir
+		}
+
+		if _, ok := deps.Node(pkgPath); ok {
+			return pkgPath, true
+		}
+
+		if dir == "." || strings.HasSuffix(file, ".go") {
+			return "", false
+		}
+	}
+}
+
+// testDirectories returns slash separated directories of the tree having test files, '.' is the root.
+func testDirectories(dirTree tree.Node) map[string]bool {
+	dirs := make(map[string]bool)
+	var walk func(node tree.Node)
+	walk = func(node tree.Node) {
+		if !node.IsDir {
+			if strings.HasSuffix(node.Name, "_test.go") {
+				dirs[path.Dir(filepath.ToSlash(node.Path))] = true
+			}
+			return
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(dirTree)
+
+	return dirs
+}
+
+// impactOf lists packages of the served module affected by changes since base revision.
+func impactOf(ctx context.Context, current views, base string) (impactSummary, error) {
+	files, err := changedFiles(ctx, base)
+	if err != nil {
+		return impactSummary{}, fmt.Errorf("changed files: %w", err)
+	}
+
+	return computeImpact(current.deps, current.testImports, current.dirTree, current.modulePath, files), nil
+}
@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+func TestComputeImpact(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{{ID: "example.com/app"}, {ID: "example.com/app/api"}, {ID: "example.com/app/db"}, {ID: "example.com/app/tool"}},
+		Edges: []graph.Edge{
+			{From: "example.com/app", To: "example.com/app/api"},
+			{From: "example.com/app/api", To: "example.com/app/db"},
+		},
+	}
+
+	dirTree := tree.Node{Path: ".", IsDir: true, Children: []tree.Node{
+		{Name: "main.go", Path: "main.go"},
+		{Name: "api", Path: "api", IsDir: true, Children: []tree.Node{
+			{Name: "api.go", Path: "api/api.go"},
+			{Name: "api_test.go", Path: "api/api_test.go"},
+		}},
+		{Name: "db", Path: "db", IsDir: true, Children: []tree.Node{{Name: "db.go", Path: "db/db.go"}}},
+		{Name: "tool", Path: "tool", IsDir: true, Children: []tree.Node{{Name: "tool_test.go", Path: "tool/tool_test.go"}}},
+	}}
+
+	want := impactSummary{
+		Changed:    []string{"example.com/app/db"},
+		Dependents: []string{"example.com/app", "example.com/app/api"},
+		Tests:      []string{"example.com/app/api"},
+		Unmapped:   []string{"removed/removed.go"},
+	}
+
+	// act
+	got := computeImpact(deps, nil, dirTree, "example.com/app", []string{"db/db.go", "db/testdata/rows.json", "removed/removed.go"})
+
+	// assert
+	assert.Equal(t, want, got)
+
+	all := computeImpact(deps, nil, dirTree, "example.com/app", []string{"go.sum"})
+	assert.Len(t, all.Changed, 4)
+	assert.Equal(t, []string{"example.com/app/api", "example.com/app/tool"}, all.Tests)
+}
+
+func TestComputeImpactOfTests(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{{ID: "example.com/app/api"}, {ID: "example.com/app/db"}, {ID: "example.com/app/e2e"}},
+		Edges: []graph.Edge{{From: "example.com/app/e2e", To: "example.com/app/api"}},
+	}
+	// Only test files of 'api' import 'db', 'e2e' importing 'api' isn't affected by that.
+	testImports := map[[2]string]bool{{"example.com/app/api", "example.com/app/db"}: true}
+
+	dirTree := tree.Node{Path: ".", IsDir: true, Children: []tree.Node{
+		{Name: "api", Path: "api", IsDir: true, Children: []tree.Node{
+			{Name: "api.go", Path: "api/api.go"},
+			{Name: "api_test.go", Path: "api/api_test.go"},
+		}},
+		{Name: "db", Path: "db", IsDir: true, Children: []tree.Node{{Name: "db.go", Path: "db/db.go"}}},
+		{Name: "e2e", Path: "e2e", IsDir: true, Children: []tree.Node{{Name: "e2e_test.go", Path: "e2e/e2e_test.go"}}},
+	}}
+
+	want := impactSummary{
+		Changed:    []string{"example.com/app/db"},
+		Dependents: []string{"example.com/app/api"},
+		Tests:      []string{"example.com/app/api"},
+		Unmapped:   []string{},
+	}
+
+	// act
+	got := computeImpact(deps, testImports, dirTree, "example.com/app", []string{"db/db.go"})
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestImpactOfTestOnlyImporter(t *testing.T) {
+	// arrange
+	t.Chdir(t.TempDir())
+	for file, content := range map[string]string{
+		"go.mod":          "module example.com/app\n\ngo 1.21\n",
+		"db/db.go":        "package db\n\nfunc Open() {}\n",
+		"api/api.go":      "package api\n",
+		"api/api_test.go": "package api_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/app/db\"\n)\n\nfunc TestOpen(t *testing.T) { db.Open() }\n",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+
+	dirTree, err := tree.BuildTree(".", false)
+	assert.NoError(t, err)
+	deps := graph.Graph{Nodes: []graph.Node{{ID: "example.com/app/api"}, {ID: "example.com/app/db"}}}
+
+	// act
+	_, testImports, err := loadTestDeps(context.Background(), "example.com/app", "./...")
+	got := computeImpact(deps, testImports, dirTree, "example.com/app", []string{"db/db.go"})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"example.com/app/api"}, got.Tests)
+}
+
+func TestChangedFiles(t *testing.T) {
+	// arrange
+	ctx := context.Background()
+	t.Chdir(t.TempDir())
+	run := func(args ...string) {
+		_, err := git(ctx, args...)
+		assert.NoError(t, err)
+	}
+	write := func(file, content string) {
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "dev@example.com")
+	run("config", "user.name", "dev")
+	write("main.go", "package main")
+	run("add", "-A")
+	run("commit", "-q", "-m", "initial")
+
+	run("checkout", "-q", "-b", "feature")
+	write("feature.go", "package main")
+	run("add", "-A")
+	run("commit", "-q", "-m", "feature")
+
+	// Commit on base branch after the fork isn't a change of the branch.
+	run("checkout", "-q", "main")
+	write("upstream.go", "package main")
+	run("add", "-A")
+	run("commit", "-q", "-m", "upstream")
+	run("checkout", "-q", "feature")
+
+	write("main.go", "package main\n\nfunc main() {}")
+	write("untracked.go", "package main")
+
+	// act
+	files, err := changedFiles(ctx, "main")
+
+	// assert
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"feature.go", "main.go", "untracked.go"}, files)
+}
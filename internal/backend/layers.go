@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/modules"
+)
+
+// Layers of dependencies outside the module the graph may show.
+const (
+	layerStd     = "std"
+	layerModules = "modules"
+)
+
+// withLayers adds the standard library and external modules imported by packages of the graph,
+// one node per module with dashed edges weighted by the number of imported packages.
+func withLayers(deps graph.Graph, imported []modules.Module, layers []string) graph.Graph {
+	inGraph := make(map[string]bool, len(deps.Nodes))
+	for _, node := range deps.Nodes {
+		inGraph[node.ID] = true
+	}
+
+	layered := deps
+	layered.Nodes = slices.Clone(deps.Nodes)
+	layered.Edges = slices.Clone(deps.Edges)
+
+	for _, module := range imported {
+		layer := layerModules
+		if module.Path == modules.StdPath {
+			layer = layerStd
+		}
+
+		if !slices.Contains(layers, layer) || inGraph[module.ID()] {
+			continue
+		}
+
+		var edges []graph.Edge
+		for _, importer := range slices.Sorted(maps.Keys(module.Importers)) {
+			if !inGraph[importer] {
+				continue
+			}
+
+			attrs := weightAttrs(len(module.Importers[importer]))
+			attrs["style"] = "dashed"
+			edges = append(edges, graph.Edge{From: importer, To: module.ID(), Attrs: attrs})
+		}
+
+		if len(edges) == 0 {
+			continue
+		}
+
+		layered.Nodes = append(layered.Nodes, graph.Node{ID: module.ID(), Attrs: layerNodeAttrs(module)})
+		layered.Edges = append(layered.Edges, edges...)
+	}
+
+	return layered
+}
+
+func layerNodeAttrs(module modules.Module) map[string]string {
+	label := fmt.Sprintf(`%s\n%s\n%d packages`, module.Path, module.Version, len(module.Packages()))
+	if module.Path == modules.StdPath {
+		label = fmt.Sprintf(`standard library\n%d packages`, len(module.Packages()))
+	}
+
+	return map[string]string{
+		"label":     label,
+		"shape":     "box3d",
+		"style":     "filled",
+		"fillcolor": "#eeeeee",
+		"tooltip":   module.ID(),
+	}
+}
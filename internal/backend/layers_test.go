@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/modules"
+)
+
+func TestWithLayers(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Nodes: []graph.Node{{ID: "example.com/app"}, {ID: "example.com/app/worker"}},
+		Edges: []graph.Edge{{From: "example.com/app", To: "example.com/app/worker"}},
+	}
+	imported := []modules.Module{
+		{Path: "golang.org/x/text", Version: "v0.14.0", Importers: map[string][]string{
+			"example.com/app/worker": {"golang.org/x/text/language", "golang.org/x/text/message"},
+		}},
+		{Path: modules.StdPath, Importers: map[string][]string{"example.com/app": {"fmt"}}},
+	}
+
+	// act
+	got := withLayers(deps, imported, []string{layerModules})
+
+	// assert
+	assert.Len(t, got.Nodes, 3)
+	assert.Equal(t, "golang.org/x/text@v0.14.0", got.Nodes[2].ID)
+	assert.Equal(t, `golang.org/x/text\nv0.14.0\n2 packages`, got.Nodes[2].Attrs["label"])
+	assert.Equal(t, []graph.Edge{
+		{From: "example.com/app", To: "example.com/app/worker"},
+		{From: "example.com/app/worker", To: "golang.org/x/text@v0.14.0", Attrs: map[string]string{
+			"label":    "2",
+			"penwidth": "2",
+			"weight":   "2",
+			"tooltip":  "2 imports",
+			"style":    "dashed",
+		}},
+	}, got.Edges)
+	assert.Len(t, deps.Nodes, 2, "source graph isn't changed")
+
+	all := withLayers(deps, imported, []string{layerStd, layerModules})
+	assert.Len(t, all.Nodes, 4)
+	assert.Equal(t, `standard library\n1 packages`, all.Nodes[3].Attrs["label"])
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"log"
+	"net/http"
+	"sync"
+)
+
+// snapshot is the page with everything it's built from.
+type snapshot struct {
+	page    []byte
+	views   views
+	callvis http.Handler
+	// expressions are graphs of other package expressions built from the same sources.
+	expressions *expressionGraphs
+	// settings and renderer the page is composed with, variants of the page reuse them.
+	settings pageSettings
+	renderer Renderer
+}
+
+// livePage serves the latest rendered page and notifies browsers when it changes.
+type livePage struct {
+	mu      sync.RWMutex
+	current snapshot
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan struct{}]struct{}
+}
+
+func newLivePage(current snapshot) *livePage {
+	return &livePage{
+		current:     current,
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+}
+
+// ServeHTTP writes the page, query parameters render its variant, see parseVariant.
+func (p *livePage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	current := p.snapshot()
+
+	variant, ok := parseVariant(r.URL.Query(), current.views.collapsed)
+	if !ok {
+		w.Write(current.page)
+		return
+	}
+
+	page, err := current.variantPage(r.Context(), variant)
+	if err != nil {
+		log.Println("failed to render page variant: ", err)
+		http.Error(w, "failed to render page variant", http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(page)
+}
+
+func (p *livePage) snapshot() snapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.current
+}
+
+// update replaces the page and notifies subscribed browsers.
+func (p *livePage) update(current snapshot) {
+	p.mu.Lock()
+	p.current = current
+	p.mu.Unlock()
+
+	p.subscribersMu.Lock()
+	defer p.subscribersMu.Unlock()
+
+	log.Printf("page updated. notify '%d' browsers", len(p.subscribers))
+	for ch := range p.subscribers {
+		// Subscriber reloads page anyway, no need to queue several updates.
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// callvisHandler delegates to the current go-callvis handler.
+func (p *livePage) callvisHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callvis := p.snapshot().callvis
+
+		if callvis == nil {
+			http.Error(w, "call graph is not available", http.StatusNotFound)
+			return
+		}
+
+		callvis.ServeHTTP(w, r)
+	})
+}
+
+// eventsHandler streams page updates as server-sent events.
+func (p *livePage) eventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		ch := p.subscribe()
+		defer p.unsubscribe(ch)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ch:
+				if _, err := w.Write([]byte("event: update\ndata: {}\n\n")); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+func (p *livePage) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	p.subscribersMu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.subscribersMu.Unlock()
+
+	return ch
+}
+
+func (p *livePage) unsubscribe(ch chan struct{}) {
+	p.subscribersMu.Lock()
+	delete(p.subscribers, ch)
+	p.subscribersMu.Unlock()
+}
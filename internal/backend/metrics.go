@@ -0,0 +1,28 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alexuserid/go-codevis/internal/backend/metrics"
+)
+
+// metricsColumns returns tree columns with package metrics by tree entry id.
+func metricsColumns(pkgMetrics map[string]metrics.Package, modulePath string, rootID string) map[string]string {
+	columns := make(map[string]string, len(pkgMetrics))
+	for pkgPath, pkg := range pkgMetrics {
+		dir := packageDir(pkgPath, modulePath)
+		switch dir {
+		case "":
+			continue
+		case ".":
+			dir = rootID
+		default:
+			dir = filepath.FromSlash(dir)
+		}
+
+		columns[dir] = fmt.Sprintf("%d loc, %d files, I=%.2f, A=%.2f", pkg.LOC, pkg.Files, pkg.Instability, pkg.Abstractness)
+	}
+
+	return columns
+}
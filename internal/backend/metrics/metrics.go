@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+// Package are metrics of a go package. Test files aren't counted.
+type Package struct {
+	Path string `json:"path"`
+	// Ca is afferent coupling, the number of packages importing the package.
+	Ca int `json:"ca"`
+	// Ce is efferent coupling, the number of packages the package imports.
+	Ce int `json:"ce"`
+	// Instability is Ce / (Ca + Ce): 0 for packages many depend on, 1 for packages depending on others only.
+	Instability float64 `json:"instability"`
+	// Abstractness is the ratio of interfaces to all declared types.
+	Abstractness float64 `json:"abstractness"`
+	// LOC is the number of non-blank lines.
+	LOC      int `json:"loc"`
+	Files    int `json:"files"`
+	Exported int `json:"exported"`
+}
+
+// Load computes metrics of packages matching patterns in the working directory.
+// Coupling is counted on the package graph, so it's consistent with the image.
+// Packages with unreadable or unparsable files are skipped.
+func Load(ctx context.Context, deps graph.Graph, patterns ...string) (map[string]Package, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles,
+	}
+
+	loaded, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	result := make(map[string]Package, len(loaded))
+	for _, pkg := range loaded {
+		metrics, err := fileMetrics(pkg.GoFiles)
+		if err != nil {
+			// A file being edited shouldn't hide metrics of other packages.
+			log.Printf("skip metrics of package '%s': %s", pkg.PkgPath, err)
+			continue
+		}
+
+		metrics.Path = pkg.PkgPath
+		metrics.Ca = len(deps.ImportedBy(pkg.PkgPath))
+		metrics.Ce = len(deps.Imports(pkg.PkgPath))
+		if metrics.Ca+metrics.Ce > 0 {
+			metrics.Instability = float64(metrics.Ce) / float64(metrics.Ca+metrics.Ce)
+		}
+
+		result[pkg.PkgPath] = metrics
+	}
+
+	return result, nil
+}
+
+// fileMetrics computes size and declaration metrics of package files.
+func fileMetrics(paths []string) (Package, error) {
+	fset := token.NewFileSet()
+
+	var metrics Package
+	var types, interfaces int
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return Package{}, fmt.Errorf("read file: %w", err)
+		}
+
+		file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return Package{}, fmt.Errorf("parse file: %w", err)
+		}
+
+		fileTypes, fileInterfaces, exported := countDecls(file)
+
+		metrics.Files++
+		metrics.LOC += countLines(src)
+		metrics.Exported += exported
+		types += fileTypes
+		interfaces += fileInterfaces
+	}
+
+	if types > 0 {
+		metrics.Abstractness = float64(interfaces) / float64(types)
+	}
+
+	return metrics, nil
+}
+
+// countDecls counts top level types, interfaces among them and exported identifiers.
+// Exported methods are counted when their receiver type is exported.
+func countDecls(file *ast.File) (int, int, int) {
+	var types, interfaces, exported int
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.IsExported() && (decl.Recv == nil || ast.IsExported(receiverName(decl.Recv))) {
+				exported++
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					types++
+					if _, ok := spec.Type.(*ast.InterfaceType); ok {
+						interfaces++
+					}
+					if spec.Name.IsExported() {
+						exported++
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							exported++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return types, interfaces, exported
+}
+
+// receiverName returns method receiver type name, e.g. 'T' of '*T' or 'T[K]'.
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+
+	expr := recv.List[0].Type
+	for {
+		switch typed := expr.(type) {
+		case *ast.StarExpr:
+			expr = typed.X
+		case *ast.IndexExpr:
+			expr = typed.X
+		case *ast.IndexListExpr:
+			expr = typed.X
+		case *ast.Ident:
+			return typed.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func countLines(src []byte) int {
+	var lines int
+	for _, line := range bytes.Split(src, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines++
+		}
+	}
+
+	return lines
+}
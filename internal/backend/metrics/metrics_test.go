@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+const source = `package sample
+
+type Reader interface{ Read() }
+
+type file struct{}
+
+type File struct{}
+
+func (f *File) Read() {}
+
+func (f file) Close() {}
+
+func Open() *File { return nil }
+
+var (
+	Default, fallback = Open(), Open()
+)
+
+const limit = 1
+`
+
+func TestCountDecls(t *testing.T) {
+	// arrange
+	file, err := parser.ParseFile(token.NewFileSet(), "sample.go", source, 0)
+	assert.NoError(t, err)
+
+	// act
+	types, interfaces, exported := countDecls(file)
+
+	// assert
+	assert.Equal(t, 3, types)
+	assert.Equal(t, 1, interfaces)
+	// Reader, File, File.Read, Open, Default.
+	assert.Equal(t, 5, exported)
+}
+
+func TestCountLines(t *testing.T) {
+	assert.Equal(t, 11, countLines([]byte(source)))
+}
+
+func TestLoadSkipsUnparsablePackage(t *testing.T) {
+	// arrange
+	t.Chdir(t.TempDir())
+	for file, content := range map[string]string{
+		"go.mod":        "module example.com/m\n\ngo 1.21\n",
+		"good/good.go":  source,
+		"broken/ok.go":  "package broken\n",
+		"broken/bad.go": "package broken\n\nfunc {",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+
+	// act
+	got, err := Load(context.Background(), graph.Graph{}, "./...")
+
+	// assert
+	assert.NoError(t, err)
+	assert.Contains(t, got, "example.com/m/good")
+	assert.Equal(t, 1, got["example.com/m/good"].Files)
+	assert.NotContains(t, got, "example.com/m/broken")
+}
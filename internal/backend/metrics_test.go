@@ -0,0 +1,27 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alexuserid/go-codevis/internal/backend/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsColumns(t *testing.T) {
+	// arrange
+	pkgMetrics := map[string]metrics.Package{
+		"example.com/m":            {LOC: 10, Files: 1},
+		"example.com/m/internal/a": {LOC: 120, Files: 3, Instability: 0.5, Abstractness: 0.25},
+		"golang.org/x/text":        {LOC: 1000, Files: 10},
+	}
+
+	// act
+	got := metricsColumns(pkgMetrics, "example.com/m", "/src/m")
+
+	// assert
+	assert.Equal(t, map[string]string{
+		"/src/m":                         "10 loc, 1 files, I=0.00, A=0.00",
+		filepath.FromSlash("internal/a"): "120 loc, 3 files, I=0.50, A=0.25",
+	}, got)
+}
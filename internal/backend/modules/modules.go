@@ -0,0 +1,126 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// StdPath is the path of the standard library pseudo module.
+const StdPath = "std"
+
+// Module is the standard library or an external module imported by packages of the main module.
+// Only direct imports are counted, modules the main module depends on through other modules aren't included.
+type Module struct {
+	// Path is the module path, StdPath for the standard library.
+	Path string `json:"path"`
+	// Version is the required version, or the directory a module is replaced with.
+	Version string `json:"version,omitempty"`
+	// Importers maps packages of the main module to sorted packages of this module they import.
+	Importers map[string][]string `json:"importers"`
+}
+
+// ID is the graph node id of the module, e.g. 'golang.org/x/text@v0.14.0' or 'std'.
+func (m Module) ID() string {
+	if m.Version == "" {
+		return m.Path
+	}
+
+	return m.Path + "@" + m.Version
+}
+
+// Packages returns sorted packages of the module imported by the main module.
+func (m Module) Packages() []string {
+	var imported []string
+	for _, pkgs := range m.Importers {
+		for _, pkg := range pkgs {
+			if !slices.Contains(imported, pkg) {
+				imported = append(imported, pkg)
+			}
+		}
+	}
+
+	sort.Strings(imported)
+
+	return imported
+}
+
+// Load groups imports of packages matching patterns in the working directory by modules they come from.
+// Imports between packages of the same module aren't included. Modules are sorted by path.
+// Imports of dependencies aren't walked, e.g. the standard library packages imported by 'golang.org/x/text'
+// count only when a matching package imports them too.
+func Load(ctx context.Context, patterns ...string) ([]Module, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+	}
+
+	loaded, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	return group(loaded), nil
+}
+
+// group collects direct imports of the packages, the graph has edges from the packages only.
+func group(pkgs []*packages.Package) []Module {
+	byID := make(map[string]*Module)
+	for _, pkg := range pkgs {
+		for _, imported := range pkg.Imports {
+			path, version, ok := moduleOf(pkg, imported)
+			if !ok {
+				continue
+			}
+
+			module := &Module{Path: path, Version: version, Importers: make(map[string][]string)}
+			if existing, ok := byID[module.ID()]; ok {
+				module = existing
+			}
+			byID[module.ID()] = module
+
+			module.Importers[pkg.PkgPath] = append(module.Importers[pkg.PkgPath], imported.PkgPath)
+		}
+	}
+
+	modules := make([]Module, 0, len(byID))
+	for _, module := range byID {
+		for _, imported := range module.Importers {
+			sort.Strings(imported)
+		}
+		modules = append(modules, *module)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].ID() < modules[j].ID()
+	})
+
+	return modules
+}
+
+// moduleOf returns module of the imported package unless it's the importer module.
+func moduleOf(importer *packages.Package, imported *packages.Package) (string, string, bool) {
+	if imported.Module == nil {
+		// Standard library packages have no module and no dots in the first path element.
+		first, _, _ := strings.Cut(imported.PkgPath, "/")
+		return StdPath, "", !strings.Contains(first, ".")
+	}
+
+	if importer.Module != nil && importer.Module.Path == imported.Module.Path {
+		return "", "", false
+	}
+
+	version := imported.Module.Version
+	if replace := imported.Module.Replace; replace != nil {
+		version = replace.Version
+		if version == "" {
+			version = replace.Path
+		}
+	}
+
+	return imported.Module.Path, version, true
+}
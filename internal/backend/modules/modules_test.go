@@ -0,0 +1,79 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestGroup(t *testing.T) {
+	// arrange
+	app := &packages.Module{Path: "example.com/app", Main: true}
+	text := &packages.Module{Path: "golang.org/x/text", Version: "v0.14.0"}
+	local := &packages.Module{Path: "example.com/lib", Replace: &packages.Module{Path: "../lib"}}
+
+	fmtPkg := &packages.Package{PkgPath: "fmt"}
+	language := &packages.Package{PkgPath: "golang.org/x/text/language", Module: text}
+	message := &packages.Package{PkgPath: "golang.org/x/text/message", Module: text}
+	lib := &packages.Package{PkgPath: "example.com/lib", Module: local}
+	worker := &packages.Package{PkgPath: "example.com/app/worker", Module: app}
+
+	input := []*packages.Package{
+		{
+			PkgPath: "example.com/app",
+			Module:  app,
+			Imports: map[string]*packages.Package{"fmt": fmtPkg, "golang.org/x/text/message": message, "example.com/app/worker": worker},
+		},
+		{
+			PkgPath: "example.com/app/worker",
+			Module:  app,
+			Imports: map[string]*packages.Package{"golang.org/x/text/language": language, "golang.org/x/text/message": message, "example.com/lib": lib},
+		},
+	}
+
+	want := []Module{
+		{Path: "example.com/lib", Version: "../lib", Importers: map[string][]string{"example.com/app/worker": {"example.com/lib"}}},
+		{Path: "golang.org/x/text", Version: "v0.14.0", Importers: map[string][]string{
+			"example.com/app":        {"golang.org/x/text/message"},
+			"example.com/app/worker": {"golang.org/x/text/language", "golang.org/x/text/message"},
+		}},
+		{Path: StdPath, Importers: map[string][]string{"example.com/app": {"fmt"}}},
+	}
+
+	// act
+	got := group(input)
+
+	// assert
+	assert.Equal(t, want, got)
+	assert.Equal(t, "golang.org/x/text@v0.14.0", got[1].ID())
+	assert.Equal(t, []string{"golang.org/x/text/language", "golang.org/x/text/message"}, got[1].Packages())
+}
+
+func TestGroupDirectImportsOnly(t *testing.T) {
+	// arrange
+	app := &packages.Module{Path: "example.com/app", Main: true}
+	text := &packages.Module{Path: "golang.org/x/text", Version: "v0.14.0"}
+	sys := &packages.Module{Path: "golang.org/x/sys", Version: "v0.20.0"}
+
+	unix := &packages.Package{PkgPath: "golang.org/x/sys/unix", Module: sys}
+	language := &packages.Package{
+		PkgPath: "golang.org/x/text/language",
+		Module:  text,
+		Imports: map[string]*packages.Package{"golang.org/x/sys/unix": unix},
+	}
+
+	input := []*packages.Package{{
+		PkgPath: "example.com/app",
+		Module:  app,
+		Imports: map[string]*packages.Package{"golang.org/x/text/language": language},
+	}}
+
+	// act
+	got := group(input)
+
+	// assert
+	assert.Equal(t, []Module{
+		{Path: "golang.org/x/text", Version: "v0.14.0", Importers: map[string][]string{"example.com/app": {"golang.org/x/text/language"}}},
+	}, got, "modules imported by other modules only aren't included")
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Graph renderer names.
+const (
+	// RendererAuto uses 'dot' when it's installed and embedded graphviz otherwise.
+	RendererAuto     = "auto"
+	RendererDot      = "dot"
+	RendererEmbedded = "embedded"
+)
+
+// Renderer lays out graphviz dot source and renders it to svg.
+type Renderer interface {
+	Name() string
+	RenderSVG(ctx context.Context, dot []byte) ([]byte, error)
+}
+
+var ErrUnknownRenderer = errors.New("unknown renderer")
+
+func newRenderer(name string) (Renderer, error) {
+	switch name {
+	case RendererDot:
+		path, err := exec.LookPath("dot")
+		if err != nil {
+			return nil, fmt.Errorf("lookup 'dot' util, graphviz: %w", err)
+		}
+
+		return dotRenderer{path: path}, nil
+	case RendererEmbedded:
+		return embeddedRenderer{}, nil
+	case RendererAuto, "":
+		if path, err := exec.LookPath("dot"); err == nil {
+			return dotRenderer{path: path}, nil
+		}
+
+		return embeddedRenderer{}, nil
+	}
+
+	return nil, fmt.Errorf("%w '%s', use '%s', '%s' or '%s'", ErrUnknownRenderer, name, RendererAuto, RendererDot, RendererEmbedded)
+}
+
+// dotRenderer runs external graphviz 'dot' util.
+type dotRenderer struct {
+	path string
+}
+
+func (r dotRenderer) Name() string {
+	return RendererDot + " (" + r.path + ")"
+}
+
+func (r dotRenderer) RenderSVG(ctx context.Context, dot []byte) ([]byte, error) {
+	cmdGraphviz := exec.CommandContext(ctx, r.path, "-T", "svg")
+	cmdGraphviz.Stdin = bytes.NewReader(dot)
+
+	stderr := bytes.NewBuffer(nil)
+	cmdGraphviz.Stderr = stderr
+
+	image, err := cmdGraphviz.Output()
+	if err != nil {
+		return nil, fmt.Errorf("graphviz output '%s': %w", stderr.String(), err)
+	}
+
+	return image, nil
+}
+
+// embeddedRenderer uses graphviz library linked into the binary.
+type embeddedRenderer struct{}
+
+func (embeddedRenderer) Name() string {
+	return RendererEmbedded
+}
@@ -0,0 +1,29 @@
+//go:build cgo
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/goccy/go-graphviz"
+)
+
+func (embeddedRenderer) RenderSVG(ctx context.Context, dot []byte) ([]byte, error) {
+	graph, err := graphviz.ParseBytes(dot)
+	if err != nil {
+		return nil, fmt.Errorf("parse dot: %w", err)
+	}
+	defer graph.Close()
+
+	g := graphviz.New()
+	defer g.Close()
+
+	image := bytes.NewBuffer(nil)
+	if err := g.Render(graph, graphviz.SVG, image); err != nil {
+		return nil, fmt.Errorf("render graph: %w", err)
+	}
+
+	return image.Bytes(), nil
+}
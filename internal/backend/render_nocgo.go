@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package backend
+
+import (
+	"context"
+	"errors"
+)
+
+var errEmbeddedRendererNoCgo = errors.New("embedded renderer requires binary built with cgo, install graphviz 'dot' util instead")
+
+func (embeddedRenderer) RenderSVG(ctx context.Context, dot []byte) ([]byte, error) {
+	return nil, errEmbeddedRendererNoCgo
+}
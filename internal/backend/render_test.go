@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRenderer(t *testing.T) {
+	t.Run("embedded", func(t *testing.T) {
+		// act
+		got, err := newRenderer(RendererEmbedded)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, embeddedRenderer{}, got)
+	})
+
+	t.Run("auto without dot", func(t *testing.T) {
+		// arrange
+		t.Setenv("PATH", t.TempDir())
+
+		// act
+		got, err := newRenderer(RendererAuto)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, embeddedRenderer{}, got)
+	})
+
+	t.Run("dot without dot", func(t *testing.T) {
+		// arrange
+		t.Setenv("PATH", t.TempDir())
+
+		// act
+		_, err := newRenderer(RendererDot)
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		// act
+		_, err := newRenderer("neato")
+
+		// assert
+		assert.ErrorIs(t, err, ErrUnknownRenderer)
+	})
+}
@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+// DefaultFile is the rules file looked up in the module root.
+const DefaultFile = ".codevis.yaml"
+
+var ErrInvalidRule = errors.New("invalid rule")
+
+// Config is the rules file content.
+//
+//	rules:
+//	  - name: domain is independent
+//	    from: internal/domain/...
+//	    deny: [internal/infra/...]
+//	  - from: internal/api/...
+//	    allow: [internal/service/..., internal/domain/...]
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule restricts imports of packages matching From.
+// Patterns use go list syntax: 'internal/...' matches 'internal' and all packages below.
+// Pattern matches either package import path or its directory relative to the module root.
+type Rule struct {
+	Name string `yaml:"name"`
+	From string `yaml:"from"`
+	// Deny lists packages which must not be imported.
+	Deny []string `yaml:"deny"`
+	// Allow lists the only module packages which may be imported.
+	// Packages outside the module aren't restricted by it.
+	Allow []string `yaml:"allow"`
+}
+
+// Violation is an import breaking a rule.
+type Violation struct {
+	Rule   string `json:"rule"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// LoadFile reads rules file.
+func LoadFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("unmarshal yaml: %w", err)
+	}
+
+	for i, rule := range cfg.Rules {
+		if rule.From == "" {
+			return Config{}, fmt.Errorf("%w: rule %d '%s' has no 'from' pattern", ErrInvalidRule, i+1, rule.Name)
+		}
+
+		if len(rule.Deny) == 0 && len(rule.Allow) == 0 {
+			return Config{}, fmt.Errorf("%w: rule %d '%s' has neither 'deny' nor 'allow' patterns", ErrInvalidRule, i+1, rule.Name)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Check returns imports of the graph violating the rules.
+// modulePath is needed to match patterns relative to the module root.
+func Check(cfg Config, deps graph.Graph, modulePath string) []Violation {
+	var violations []Violation
+	for i, rule := range cfg.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		from := MatchPattern(rule.From)
+		deny := matchAny(rule.Deny)
+		allow := matchAny(rule.Allow)
+
+		for _, edge := range deps.Edges {
+			if !matchPackage(from, edge.From, modulePath) {
+				continue
+			}
+
+			if pattern, ok := deny(edge.To, modulePath); ok {
+				violations = append(violations, Violation{
+					Rule:   name,
+					From:   edge.From,
+					To:     edge.To,
+					Reason: fmt.Sprintf("'%s' must not import '%s'", rule.From, pattern),
+				})
+				continue
+			}
+
+			if len(rule.Allow) == 0 || !inModule(edge.To, modulePath) {
+				continue
+			}
+
+			if _, ok := allow(edge.To, modulePath); !ok {
+				violations = append(violations, Violation{
+					Rule:   name,
+					From:   edge.From,
+					To:     edge.To,
+					Reason: fmt.Sprintf("'%s' may import only '%s'", rule.From, strings.Join(rule.Allow, "', '")),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].From != violations[j].From {
+			return violations[i].From < violations[j].From
+		}
+		return violations[i].To < violations[j].To
+	})
+
+	return violations
+}
+
+// MatchPattern returns matcher of go list like pattern, where '...' matches any string.
+func MatchPattern(pattern string) func(name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	// 'foo/...' matches 'foo' too.
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+
+	return regexp.MustCompile(`^` + re + `$`).MatchString
+}
+
+// matchAny returns matcher reporting the first matching pattern.
+func matchAny(patterns []string) func(pkgPath string, modulePath string) (string, bool) {
+	matchers := make([]func(string) bool, len(patterns))
+	for i, pattern := range patterns {
+		matchers[i] = MatchPattern(pattern)
+	}
+
+	return func(pkgPath string, modulePath string) (string, bool) {
+		for i, match := range matchers {
+			if matchPackage(match, pkgPath, modulePath) {
+				return patterns[i], true
+			}
+		}
+
+		return "", false
+	}
+}
+
+// matchPackage matches package import path or its path relative to the module root.
+func matchPackage(match func(string) bool, pkgPath string, modulePath string) bool {
+	if match(pkgPath) {
+		return true
+	}
+
+	if pkgPath == modulePath {
+		return match(".")
+	}
+
+	rel, ok := strings.CutPrefix(pkgPath, modulePath+"/")
+	return ok && match(rel)
+}
+
+func inModule(pkgPath string, modulePath string) bool {
+	return pkgPath == modulePath || strings.HasPrefix(pkgPath, modulePath+"/")
+}
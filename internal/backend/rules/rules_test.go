@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+const modulePath = "github.com/username/tmp"
+
+func TestMatchPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "internal/...", name: "internal", want: true},
+		{pattern: "internal/...", name: "internal/worker", want: true},
+		{pattern: "internal/...", name: "internalx", want: false},
+		{pattern: "./internal/...", name: "internal/worker", want: true},
+		{pattern: "internal/.../infra", name: "internal/a/b/infra", want: true},
+		{pattern: "internal/worker", name: "internal/worker/job", want: false},
+		{pattern: "golang.org/x/...", name: "golang.org/x/text/language", want: true},
+	} {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, MatchPattern(tc.pattern)(tc.name))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// arrange
+	deps := graph.Graph{
+		Edges: []graph.Edge{
+			{From: modulePath + "/internal/domain/order", To: modulePath + "/internal/infra/db"},
+			{From: modulePath + "/internal/domain/order", To: modulePath + "/internal/domain/money"},
+			{From: modulePath + "/internal/api", To: modulePath + "/internal/service"},
+			{From: modulePath + "/internal/api", To: modulePath + "/internal/infra/db"},
+			{From: modulePath + "/internal/api", To: "golang.org/x/text/language"},
+			{From: modulePath + "/cmd/app", To: modulePath + "/internal/infra/db"},
+		},
+	}
+
+	cfg := Config{
+		Rules: []Rule{
+			{Name: "domain is independent", From: "internal/domain/...", Deny: []string{"internal/infra/..."}},
+			{From: "internal/api", Allow: []string{"internal/service/...", "internal/domain/..."}},
+		},
+	}
+
+	want := []Violation{
+		{
+			Rule:   "rule 2",
+			From:   modulePath + "/internal/api",
+			To:     modulePath + "/internal/infra/db",
+			Reason: "'internal/api' may import only 'internal/service/...', 'internal/domain/...'",
+		},
+		{
+			Rule:   "domain is independent",
+			From:   modulePath + "/internal/domain/order",
+			To:     modulePath + "/internal/infra/db",
+			Reason: "'internal/domain/...' must not import 'internal/infra/...'",
+		},
+	}
+
+	// act
+	got := Check(cfg, deps, modulePath)
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		// arrange
+		path := filepath.Join(t.TempDir(), DefaultFile)
+		err := os.WriteFile(path, []byte(`
+rules:
+  - name: domain is independent
+    from: internal/domain/...
+    deny: [internal/infra/...]
+`), 0o644)
+		assert.NoError(t, err)
+
+		want := Config{
+			Rules: []Rule{
+				{Name: "domain is independent", From: "internal/domain/...", Deny: []string{"internal/infra/..."}},
+			},
+		}
+
+		// act
+		got, err := LoadFile(path)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("no patterns", func(t *testing.T) {
+		// arrange
+		path := filepath.Join(t.TempDir(), DefaultFile)
+		err := os.WriteFile(path, []byte("rules:\n  - from: internal/...\n"), 0o644)
+		assert.NoError(t, err)
+
+		// act
+		_, err = LoadFile(path)
+
+		// assert
+		assert.ErrorIs(t, err, ErrInvalidRule)
+	})
+}
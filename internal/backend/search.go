@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+	"github.com/alexuserid/go-codevis/internal/backend/search"
+	"github.com/alexuserid/go-codevis/internal/backend/structure"
+)
+
+// buildSearchIndex indexes packages of the graph, go files of the tree and exported symbols declared in them.
+// Unparsable files are indexed without symbols.
+func buildSearchIndex(packagesTree DirNode, deps graph.Graph, modulePath string) *search.Index {
+	var entries []search.Entry
+	for _, node := range deps.Nodes {
+		entries = append(entries, search.Entry{Kind: search.KindPackage, Name: node.ID, Package: node.ID})
+	}
+
+	fset := token.NewFileSet()
+	var walk func(dir DirNode)
+	walk = func(dir DirNode) {
+		pkgPath := modulePath
+		if !dir.IsRoot {
+			pkgPath = modulePath + "/" + filepath.ToSlash(dir.Path)
+		}
+
+		for _, file := range dir.Files {
+			if !strings.HasSuffix(file.Name, ".go") {
+				continue
+			}
+
+			entries = append(entries, search.Entry{Kind: search.KindFile, Name: file.Name, Package: pkgPath, Path: file.Path})
+
+			if strings.HasSuffix(file.Name, "_test.go") {
+				continue
+			}
+
+			parsed, err := parser.ParseFile(fset, file.Path, nil, parser.SkipObjectResolution)
+			if err != nil {
+				log.Printf("failed to index symbols of '%s': %s", file.Path, err)
+				continue
+			}
+
+			for _, symbol := range exportedSymbols(fset, parsed) {
+				symbol.Package = pkgPath
+				symbol.Path = file.Path
+				entries = append(entries, symbol)
+			}
+		}
+
+		for _, child := range dir.Children {
+			walk(child)
+		}
+	}
+	walk(packagesTree)
+
+	return search.New(entries)
+}
+
+// exportedSymbols returns exported functions, methods, types, variables and constants of file.
+func exportedSymbols(fset *token.FileSet, file *ast.File) []search.Entry {
+	var symbols []search.Entry
+	add := func(name *ast.Ident, qualified string) {
+		if name.IsExported() {
+			symbols = append(symbols, search.Entry{Kind: search.KindSymbol, Name: qualified, Line: fset.Position(name.Pos()).Line})
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch typed := decl.(type) {
+		case *ast.FuncDecl:
+			add(typed.Name, structure.DeclName(typed))
+		case *ast.GenDecl:
+			for _, spec := range typed.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						add(name, name.Name)
+					}
+				}
+			}
+		}
+	}
+
+	return symbols
+}
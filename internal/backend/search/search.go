@@ -0,0 +1,138 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kinds of indexed entries.
+const (
+	KindPackage = "package"
+	KindFile    = "file"
+	KindSymbol  = "symbol"
+)
+
+// Entry is a searchable package, file or exported symbol.
+type Entry struct {
+	Kind string `json:"kind"`
+	// Name is matched against queries: package path, file name or symbol name like 'T.Read'.
+	Name string `json:"name"`
+	// Package is the import path of the package the entry belongs to.
+	Package string `json:"package"`
+	// Path is the file relative to the module root, empty for packages.
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// Result is a matched entry, higher score matches better.
+type Result struct {
+	Entry
+	Score int `json:"score"`
+}
+
+// Index is an immutable list of entries searched with fuzzy matching.
+type Index struct {
+	entries []Entry
+}
+
+func New(entries []Entry) *Index {
+	return &Index{entries: entries}
+}
+
+// Len returns the number of indexed entries.
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+
+	return len(idx.entries)
+}
+
+// Search returns at most limit best matches of query. Empty query matches nothing.
+func (idx *Index) Search(query string, limit int) []Result {
+	query = strings.TrimSpace(query)
+	if idx == nil || query == "" || limit <= 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, entry := range idx.entries {
+		if points := Score(query, entry.Name); points > 0 {
+			results = append(results, Result{Entry: entry, Score: points})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// Score returns how well query matches text ignoring case, 0 when it doesn't.
+// Query characters must appear in text in order, e.g. 'bcv' matches 'backend/callvis.go'.
+// Consecutive characters, matches at word starts and substrings score higher, long texts score lower.
+func Score(query string, text string) int {
+	queryRunes := []rune(strings.ToLower(query))
+	textRunes := []rune(text)
+	lowerRunes := []rune(strings.ToLower(text))
+	if len(lowerRunes) != len(textRunes) {
+		// Lowering changed rune count, match as is.
+		lowerRunes = textRunes
+	}
+
+	points := 0
+	matched := 0
+	consecutive := false
+	for i := 0; i < len(lowerRunes) && matched < len(queryRunes); i++ {
+		if lowerRunes[i] != queryRunes[matched] {
+			consecutive = false
+			continue
+		}
+
+		points++
+		if consecutive {
+			points += 5
+		}
+		if wordStart(textRunes, i) {
+			points += 10
+		}
+
+		matched++
+		consecutive = true
+	}
+
+	if matched < len(queryRunes) {
+		return 0
+	}
+
+	if strings.Contains(strings.ToLower(text), strings.ToLower(query)) {
+		points += 20
+	}
+
+	points -= len(textRunes) / 10
+
+	return max(points, 1)
+}
+
+// wordStart reports whether rune at i starts a path segment, a word or a camel case hump.
+func wordStart(text []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	prev := text[i-1]
+	if strings.ContainsRune("/._-$ ", prev) {
+		return true
+	}
+
+	return unicode.IsUpper(text[i]) && unicode.IsLower(prev)
+}
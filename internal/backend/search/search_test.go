@@ -0,0 +1,42 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	assert.Zero(t, Score("xyz", "callvis.go"))
+	assert.Zero(t, Score("vc", "callvis.go"))
+	assert.Positive(t, Score("cvg", "callvis.go"))
+
+	// Substring beats scattered characters.
+	assert.Greater(t, Score("callvis", "callvis.go"), Score("callvis", "cycles_all_visible.go"))
+	// Word starts beat characters in the middle of words.
+	assert.Greater(t, Score("sr", "SearchResult"), Score("sr", "parser"))
+	// Case is ignored.
+	assert.Equal(t, Score("newindex", "NewIndex"), Score("NEWINDEX", "NewIndex"))
+}
+
+func TestSearch(t *testing.T) {
+	// arrange
+	idx := New([]Entry{
+		{Kind: KindPackage, Name: "example.com/app/internal/worker", Package: "example.com/app/internal/worker"},
+		{Kind: KindFile, Name: "worker.go", Package: "example.com/app/internal/worker", Path: "internal/worker/worker.go"},
+		{Kind: KindSymbol, Name: "Pool.Start", Package: "example.com/app/internal/worker", Path: "internal/worker/pool.go", Line: 10},
+		{Kind: KindSymbol, Name: "Render", Package: "example.com/app/internal/view", Path: "internal/view/view.go", Line: 3},
+	})
+
+	// act
+	results := idx.Search("worker", 10)
+
+	// assert
+	assert.Len(t, results, 2)
+	assert.Equal(t, "worker.go", results[0].Name)
+	assert.Equal(t, "example.com/app/internal/worker", results[1].Name)
+
+	assert.Len(t, idx.Search("start", 10), 1)
+	assert.Len(t, idx.Search("r", 1), 1)
+	assert.Empty(t, idx.Search(" ", 10))
+}
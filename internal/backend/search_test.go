@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/search"
+)
+
+func TestExportedSymbols(t *testing.T) {
+	// arrange
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pool.go", `package worker
+
+type Pool struct{}
+
+type job struct{}
+
+func (p *Pool) Start() {}
+
+func (p *Pool) stop() {}
+
+const (
+	Size, limit = 1, 2
+)
+`, 0)
+	assert.NoError(t, err)
+
+	want := []search.Entry{
+		{Kind: search.KindSymbol, Name: "Pool", Line: 3},
+		{Kind: search.KindSymbol, Name: "Pool.Start", Line: 7},
+		{Kind: search.KindSymbol, Name: "Size", Line: 12},
+	}
+
+	// act
+	got := exportedSymbols(fset, file)
+
+	// assert
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,283 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexuserid/go-codevis/internal/backend/structure"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+	"github.com/alexuserid/go-codevis/internal/web"
+)
+
+var (
+	ErrSourceNotFound      = errors.New("source file not found")
+	ErrDeclarationNotFound = errors.New("declaration not found")
+)
+
+var sourceTemplate = template.Must(template.New("source").Parse(web.SourceHTML))
+
+// sourceLine is a line of highlighted source.
+type sourceLine struct {
+	Number int
+	HTML   template.HTML
+}
+
+// registerSource serves go files of the module tree with syntax highlighting.
+// Functions are resolved to their declarations, e.g. '/source?pkg=example.com/app&func=T.Run'.
+func registerSource(mux *http.ServeMux, page *livePage) {
+	mux.Handle("GET /source", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := page.snapshot().views
+		query := r.URL.Query()
+
+		if fn := query.Get("func"); fn != "" {
+			path, line, err := findDeclaration(current.dirTree, current.modulePath, query.Get("pkg"), fn)
+			if err != nil {
+				writeSourceError(w, err)
+				return
+			}
+
+			http.Redirect(w, r, sourceURL(path, line), http.StatusFound)
+			return
+		}
+
+		file, err := sourceFile(current.dirTree, query.Get("path"))
+		if err != nil {
+			writeSourceError(w, err)
+			return
+		}
+
+		src, err := os.ReadFile(file.AbsPath)
+		if err != nil {
+			writeSourceError(w, fmt.Errorf("read source file: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = sourceTemplate.Execute(w, map[string]any{
+			"Path":  file.Path,
+			"Lines": highlightGo(src),
+		})
+		if err != nil {
+			log.Println("write source page failed: ", err)
+		}
+	}))
+}
+
+// sourceFile returns go file of the tree. Files outside the tree root aren't served,
+// even if the tree links them.
+func sourceFile(root tree.Node, path string) (tree.Node, error) {
+	path = filepath.Clean(filepath.FromSlash(path))
+	if !strings.HasSuffix(path, ".go") {
+		return tree.Node{}, fmt.Errorf("%w: '%s'", ErrSourceNotFound, path)
+	}
+
+	file, ok := findTreeNode(root, path)
+	if !ok || file.IsDir {
+		return tree.Node{}, fmt.Errorf("%w: '%s'", ErrSourceNotFound, path)
+	}
+
+	rootPath, err := filepath.EvalSymlinks(root.AbsPath)
+	if err != nil {
+		return tree.Node{}, fmt.Errorf("resolve tree root: %w", err)
+	}
+
+	filePath, err := filepath.EvalSymlinks(file.AbsPath)
+	if err != nil {
+		return tree.Node{}, fmt.Errorf("resolve source file: %w", err)
+	}
+
+	rel, err := filepath.Rel(rootPath, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return tree.Node{}, fmt.Errorf("%w: '%s'", ErrSourceNotFound, path)
+	}
+
+	return file, nil
+}
+
+func findTreeNode(root tree.Node, path string) (tree.Node, bool) {
+	if filepath.Clean(root.Path) == path {
+		return root, true
+	}
+
+	for _, child := range root.Children {
+		if node, ok := findTreeNode(child, path); ok {
+			return node, true
+		}
+	}
+
+	return tree.Node{}, false
+}
+
+// findDeclaration returns file and line of function declaration in a module package.
+func findDeclaration(root tree.Node, modulePath string, pkgPath string, fn string) (string, int, error) {
+	pkgPath, name := splitFuncName(pkgPath, fn)
+
+	dir := packageDir(pkgPath, modulePath)
+	if dir == "" {
+		return "", 0, fmt.Errorf("%w: '%s' is outside the module", ErrDeclarationNotFound, pkgPath)
+	}
+
+	pkgDir, ok := findTreeNode(root, filepath.FromSlash(dir))
+	if !ok {
+		return "", 0, fmt.Errorf("%w: no directory of '%s'", ErrDeclarationNotFound, pkgPath)
+	}
+
+	fset := token.NewFileSet()
+	for _, child := range pkgDir.Children {
+		if child.IsDir || !strings.HasSuffix(child.Name, ".go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, child.AbsPath, nil, parser.SkipObjectResolution)
+		if err != nil {
+			log.Printf("failed to parse '%s': %s", child.Path, err)
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if ok && structure.DeclName(funcDecl) == name {
+				return child.Path, fset.Position(funcDecl.Pos()).Line, nil
+			}
+		}
+	}
+
+	return "", 0, fmt.Errorf("%w: '%s' in '%s'", ErrDeclarationNotFound, name, pkgPath)
+}
+
+// splitFuncName splits go-callvis function id, e.g. '(*example.com/app.T).Run' or 'example.com/app.Run$1',
+// to package path and function name like structure.Function.Name. Names without package path belong to pkgPath.
+func splitFuncName(pkgPath string, fn string) (string, string) {
+	// Closures are shown at the function they're declared in.
+	fn, _, _ = strings.Cut(fn, "$")
+
+	if recv, method, ok := strings.Cut(fn, ")."); ok && strings.HasPrefix(recv, "(") {
+		recv = strings.TrimLeft(recv, "(*")
+		recv, _, _ = strings.Cut(recv, "[")
+
+		i := strings.LastIndex(recv, ".")
+		if i < 0 {
+			return pkgPath, recv + "." + method
+		}
+		return recv[:i], recv[i+1:] + "." + method
+	}
+
+	slash := strings.LastIndex(fn, "/")
+	if slash < 0 {
+		return pkgPath, fn
+	}
+
+	i := strings.Index(fn[slash:], ".")
+	if i < 0 {
+		return pkgPath, fn
+	}
+
+	return fn[:slash+i], fn[slash+i+1:]
+}
+
+func sourceURL(path string, line int) string {
+	return "/source?" + url.Values{"path": {filepath.ToSlash(path)}}.Encode() + "#L" + strconv.Itoa(line)
+}
+
+// highlightGo splits source to lines with tokens wrapped into spans of classes
+// 'keyword', 'string', 'comment' and 'number'. Spans don't cross lines.
+func highlightGo(src []byte) []sourceLine {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	// Errors don't stop scanning, broken tokens are left plain.
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	buf := bytes.NewBuffer(nil)
+	offset := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		// Automatically inserted semicolons aren't in the source.
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+
+		start := file.Offset(pos)
+		end := start + len(lit)
+		if lit == "" {
+			end = start + len(tok.String())
+		}
+		if start < offset || end > len(src) {
+			continue
+		}
+
+		buf.WriteString(html.EscapeString(string(src[offset:start])))
+		writeToken(buf, tokenClass(tok), string(src[start:end]))
+		offset = end
+	}
+	buf.WriteString(html.EscapeString(string(src[offset:])))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	result := make([]sourceLine, 0, len(lines))
+	for i, line := range lines {
+		result = append(result, sourceLine{Number: i + 1, HTML: template.HTML(line)})
+	}
+
+	return result
+}
+
+func writeToken(buf *bytes.Buffer, class string, text string) {
+	if class == "" {
+		buf.WriteString(html.EscapeString(text))
+		return
+	}
+
+	// Comments and raw strings may span several lines.
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if part != "" {
+			fmt.Fprintf(buf, `<span class="%s">%s</span>`, class, html.EscapeString(part))
+		}
+	}
+}
+
+func tokenClass(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return "keyword"
+	case tok == token.STRING || tok == token.CHAR:
+		return "string"
+	case tok == token.COMMENT:
+		return "comment"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return "number"
+	default:
+		return ""
+	}
+}
+
+func writeSourceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrSourceNotFound) || errors.Is(err, ErrDeclarationNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	log.Println(err)
+	http.Error(w, "read source failed", http.StatusInternalServerError)
+}
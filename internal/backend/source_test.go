@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+func TestSplitFuncName(t *testing.T) {
+	tests := []struct {
+		fn      string
+		pkgPath string
+		name    string
+	}{
+		{fn: "Run", pkgPath: "example.com/app", name: "Run"},
+		{fn: "T.Run", pkgPath: "example.com/app", name: "T.Run"},
+		{fn: "example.com/app/worker.Run", pkgPath: "example.com/app/worker", name: "Run"},
+		{fn: "example.com/app/worker.Run$1", pkgPath: "example.com/app/worker", name: "Run"},
+		{fn: "(*example.com/app/worker.Pool).Start", pkgPath: "example.com/app/worker", name: "Pool.Start"},
+		{fn: "(example.com/app/worker.Queue[int]).Push", pkgPath: "example.com/app/worker", name: "Queue.Push"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fn, func(t *testing.T) {
+			// act
+			pkgPath, name := splitFuncName("example.com/app", tt.fn)
+
+			// assert
+			assert.Equal(t, tt.pkgPath, pkgPath)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
+
+func TestHighlightGo(t *testing.T) {
+	// arrange
+	src := "package app\n\n/* a <b>\nc */\nvar x = \"s\" + 1\n"
+
+	want := []sourceLine{
+		{Number: 1, HTML: `<span class="keyword">package</span> app`},
+		{Number: 2, HTML: ``},
+		{Number: 3, HTML: `<span class="comment">/* a &lt;b&gt;</span>`},
+		{Number: 4, HTML: `<span class="comment">c */</span>`},
+		{Number: 5, HTML: `<span class="keyword">var</span> x = <span class="string">&#34;s&#34;</span> + <span class="number">1</span>`},
+	}
+
+	// act
+	got := highlightGo([]byte(src))
+
+	// assert
+	assert.Equal(t, want, got)
+}
+
+func TestSourceFile(t *testing.T) {
+	// arrange
+	outside := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret.go"), []byte("package secret\n"), 0o644))
+
+	t.Chdir(t.TempDir())
+	assert.NoError(t, os.Mkdir("app", 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join("app", "app.go"), []byte("package app\n"), 0o644))
+	assert.NoError(t, os.WriteFile("README.md", []byte("readme\n"), 0o644))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "secret.go"), filepath.Join("app", "link.go")))
+
+	root, err := tree.BuildTree(".", false)
+	assert.NoError(t, err)
+
+	// act
+	file, err := sourceFile(root, "app/app.go")
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("app", "app.go"), file.Path)
+
+	for _, path := range []string{"README.md", "../secret.go", "app/../../secret.go", "app/link.go", "app/missing.go"} {
+		_, err := sourceFile(root, path)
+		assert.ErrorIs(t, err, ErrSourceNotFound, path)
+	}
+}
+
+func TestFindDeclaration(t *testing.T) {
+	// arrange
+	t.Chdir(t.TempDir())
+	assert.NoError(t, os.Mkdir("worker", 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join("worker", "pool.go"), []byte(`package worker
+
+type Pool struct{}
+
+func (p *Pool) Start() {}
+`), 0o644))
+
+	root, err := tree.BuildTree(".", false)
+	assert.NoError(t, err)
+
+	// act
+	path, line, err := findDeclaration(root, "example.com/app", "example.com/app", "(*example.com/app/worker.Pool).Start")
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("worker", "pool.go"), path)
+	assert.Equal(t, 5, line)
+
+	_, _, err = findDeclaration(root, "example.com/app", "example.com/app/worker", "Stop")
+	assert.ErrorIs(t, err, ErrDeclarationNotFound)
+}
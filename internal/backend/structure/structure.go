@@ -0,0 +1,335 @@
+package structure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+var ErrUnknownPackage = errors.New("unknown package")
+
+// Package is the public surface and internal structure of a go package. Test files aren't included.
+type Package struct {
+	Path      string     `json:"path"`
+	Types     []Type     `json:"types"`
+	Functions []Function `json:"functions"`
+	// Calls are calls between functions of the package.
+	Calls []Call `json:"calls"`
+}
+
+// Type is a type declared in the package.
+type Type struct {
+	Name string `json:"name"`
+	// Kind is 'struct', 'interface' or 'other'.
+	Kind     string `json:"kind"`
+	Exported bool   `json:"exported"`
+	// Methods are names of functions declared with the type receiver.
+	Methods []string `json:"methods"`
+}
+
+// Function is a function or a method of the package.
+type Function struct {
+	// Name is the function name, methods are prefixed with receiver type name, e.g. 'T.Read'.
+	Name     string `json:"name"`
+	Exported bool   `json:"exported"`
+	// CalledFrom are other packages calling the function.
+	CalledFrom []string `json:"calledFrom"`
+}
+
+// Call is a call of function To by function From.
+type Call struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Load describes package pkgPath in the working directory.
+// Calls from other packages are searched in importers only.
+func Load(ctx context.Context, pkgPath string, importers ...string) (Package, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+	}
+
+	loaded, err := packages.Load(cfg, append([]string{pkgPath}, importers...)...)
+	if err != nil {
+		return Package{}, fmt.Errorf("load packages: %w", err)
+	}
+
+	var target *packages.Package
+	for _, pkg := range loaded {
+		if pkg.PkgPath == pkgPath {
+			target = pkg
+		}
+	}
+	if target == nil || target.Types == nil {
+		return Package{}, fmt.Errorf("%w: '%s'", ErrUnknownPackage, pkgPath)
+	}
+
+	result := describe(target.Types)
+	for _, pkg := range loaded {
+		result.addCalls(pkg.PkgPath, pkg.Syntax, pkg.TypesInfo)
+	}
+
+	return result, nil
+}
+
+// describe lists types and functions of the package scope.
+func describe(pkg *types.Package) Package {
+	result := Package{Path: pkg.Path()}
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			result.Functions = append(result.Functions, Function{Name: name, Exported: obj.Exported()})
+		case *types.TypeName:
+			declared := Type{Name: name, Kind: typeKind(obj.Type()), Exported: obj.Exported()}
+
+			if named, ok := obj.Type().(*types.Named); ok && !obj.IsAlias() {
+				for i := 0; i < named.NumMethods(); i++ {
+					method := named.Method(i)
+					declared.Methods = append(declared.Methods, funcName(method))
+					result.Functions = append(result.Functions, Function{Name: funcName(method), Exported: method.Exported()})
+				}
+				sort.Strings(declared.Methods)
+			}
+
+			result.Types = append(result.Types, declared)
+		}
+	}
+
+	sort.Slice(result.Functions, func(i, j int) bool { return result.Functions[i].Name < result.Functions[j].Name })
+
+	return result
+}
+
+// addCalls adds calls of the package functions found in files of package callerPath.
+func (p *Package) addCalls(callerPath string, files []*ast.File, info *types.Info) {
+	if info == nil {
+		return
+	}
+
+	functions := make(map[string]*Function, len(p.Functions))
+	for i := range p.Functions {
+		functions[p.Functions[i].Name] = &p.Functions[i]
+	}
+
+	calls := make(map[Call]bool, len(p.Calls))
+	for _, call := range p.Calls {
+		calls[call] = true
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
+			}
+
+			caller, ok := info.Defs[funcDecl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				callExpr, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+
+				callee := calledFunc(callExpr, info)
+				if callee == nil || callee.Pkg() == nil || callee.Pkg().Path() != p.Path {
+					return true
+				}
+
+				function, ok := functions[funcName(callee)]
+				if !ok {
+					return true
+				}
+
+				if callerPath != p.Path {
+					if !slices.Contains(function.CalledFrom, callerPath) {
+						function.CalledFrom = append(function.CalledFrom, callerPath)
+					}
+					return true
+				}
+
+				call := Call{From: funcName(caller), To: function.Name}
+				if _, known := functions[call.From]; known && !calls[call] {
+					calls[call] = true
+					p.Calls = append(p.Calls, call)
+				}
+
+				return true
+			})
+		}
+	}
+
+	sort.Slice(p.Calls, func(i, j int) bool {
+		if p.Calls[i].From != p.Calls[j].From {
+			return p.Calls[i].From < p.Calls[j].From
+		}
+		return p.Calls[i].To < p.Calls[j].To
+	})
+}
+
+// Graph draws functions with calls between them. Methods are attached to their types,
+// packages calling the package functions are drawn grey.
+func (p Package) Graph() graph.Graph {
+	g := graph.Graph{
+		GraphAttrs: map[string]string{"rankdir": "LR", "label": p.Path},
+		NodeAttrs:  map[string]string{"shape": "box", "fontname": "Helvetica", "fontsize": "10"},
+	}
+
+	for _, declared := range p.Types {
+		g.Nodes = append(g.Nodes, graph.Node{ID: declared.Name, Attrs: map[string]string{
+			"shape": "tab",
+			"style": visibilityStyle(declared.Exported),
+			"label": declared.Kind + " " + declared.Name,
+		}})
+
+		for _, method := range declared.Methods {
+			g.Edges = append(g.Edges, graph.Edge{From: declared.Name, To: method, Attrs: map[string]string{
+				"style":     "dotted",
+				"arrowhead": "none",
+			}})
+		}
+	}
+
+	callers := make(map[string]bool)
+	for _, function := range p.Functions {
+		attrs := map[string]string{"style": visibilityStyle(function.Exported)}
+		if len(function.CalledFrom) > 0 {
+			attrs["tooltip"] = "called from:\n" + strings.Join(function.CalledFrom, "\n")
+		}
+		g.Nodes = append(g.Nodes, graph.Node{ID: function.Name, Attrs: attrs})
+
+		for _, caller := range function.CalledFrom {
+			callers[caller] = true
+			g.Edges = append(g.Edges, graph.Edge{From: caller, To: function.Name, Attrs: map[string]string{
+				"color": "grey",
+				"style": "dashed",
+			}})
+		}
+	}
+
+	callerPaths := make([]string, 0, len(callers))
+	for caller := range callers {
+		callerPaths = append(callerPaths, caller)
+	}
+	sort.Strings(callerPaths)
+
+	for _, caller := range callerPaths {
+		g.Nodes = append(g.Nodes, graph.Node{ID: caller, Attrs: map[string]string{
+			"shape":     "ellipse",
+			"color":     "grey",
+			"fontcolor": "grey",
+		}})
+	}
+
+	for _, call := range p.Calls {
+		g.Edges = append(g.Edges, graph.Edge{From: call.From, To: call.To})
+	}
+
+	return g
+}
+
+// calledFunc returns function called by expression, nil for calls of func values and conversions.
+func calledFunc(call *ast.CallExpr, info *types.Info) *types.Func {
+	fun := ast.Unparen(call.Fun)
+	// Explicit instantiation, e.g. 'F[int]()'.
+	switch typed := fun.(type) {
+	case *ast.IndexExpr:
+		fun = typed.X
+	case *ast.IndexListExpr:
+		fun = typed.X
+	}
+
+	var ident *ast.Ident
+	switch typed := fun.(type) {
+	case *ast.Ident:
+		ident = typed
+	case *ast.SelectorExpr:
+		ident = typed.Sel
+	default:
+		return nil
+	}
+
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok {
+		return nil
+	}
+
+	return fn.Origin()
+}
+
+// funcName returns function name, methods are prefixed with receiver type name, e.g. 'T.Read' of '(*T[K]).Read'.
+func funcName(fn *types.Func) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+
+	recvType := recv.Type()
+	if pointer, ok := recvType.(*types.Pointer); ok {
+		recvType = pointer.Elem()
+	}
+
+	if named, ok := recvType.(interface{ Obj() *types.TypeName }); ok {
+		return named.Obj().Name() + "." + fn.Name()
+	}
+
+	return fn.Name()
+}
+
+func typeKind(t types.Type) string {
+	switch t.Underlying().(type) {
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	default:
+		return "other"
+	}
+}
+
+// visibilityStyle fills exported declarations, so the public surface stands out.
+func visibilityStyle(exported bool) string {
+	if exported {
+		return "filled"
+	}
+
+	return "solid"
+}
+
+// DeclName returns name of declared function like Function.Name, e.g. 'T.Read' of 'func (t *T[K]) Read()'.
+func DeclName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+
+	expr := decl.Recv.List[0].Type
+	for {
+		switch typed := expr.(type) {
+		case *ast.StarExpr:
+			expr = typed.X
+		case *ast.IndexExpr:
+			expr = typed.X
+		case *ast.IndexListExpr:
+			expr = typed.X
+		case *ast.Ident:
+			return typed.Name + "." + decl.Name.Name
+		default:
+			return decl.Name.Name
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package structure
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexuserid/go-codevis/internal/backend/graph"
+)
+
+const storeSource = `package store
+
+type Reader interface{ Read() string }
+
+type file struct{ name string }
+
+func (f *file) Read() string { return f.name }
+
+func (f file) path() string { return "/" + f.name }
+
+func Open(name string) Reader {
+	f := &file{name: name}
+	f.path()
+	return f
+}
+
+func open() {}
+`
+
+const appSource = `package app
+
+import "example.com/store"
+
+func Run() { store.Open("x").Read() }
+`
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+func check(t *testing.T, path string, source string, imported map[string]*types.Package) (*types.Package, []*ast.File, *types.Info) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path+".go", source, 0)
+	assert.NoError(t, err)
+
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}, Uses: map[*ast.Ident]types.Object{}}
+	cfg := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if pkg, ok := imported[path]; ok {
+			return pkg, nil
+		}
+		return importer.Default().Import(path)
+	})}
+
+	pkg, err := cfg.Check(path, fset, []*ast.File{file}, info)
+	assert.NoError(t, err)
+
+	return pkg, []*ast.File{file}, info
+}
+
+func TestDescribe(t *testing.T) {
+	// arrange
+	storePkg, storeFiles, storeInfo := check(t, "example.com/store", storeSource, nil)
+	_, appFiles, appInfo := check(t, "example.com/app", appSource, map[string]*types.Package{"example.com/store": storePkg})
+
+	// act
+	result := describe(storePkg)
+	result.addCalls("example.com/store", storeFiles, storeInfo)
+	result.addCalls("example.com/app", appFiles, appInfo)
+
+	// assert
+	assert.Equal(t, []Type{
+		{Name: "Reader", Kind: "interface", Exported: true},
+		{Name: "file", Kind: "struct", Methods: []string{"file.Read", "file.path"}},
+	}, result.Types)
+	assert.Equal(t, []Function{
+		{Name: "Open", Exported: true, CalledFrom: []string{"example.com/app"}},
+		{Name: "file.Read", Exported: true},
+		{Name: "file.path"},
+		{Name: "open"},
+	}, result.Functions)
+	assert.Equal(t, []Call{{From: "Open", To: "file.path"}}, result.Calls)
+}
+
+func TestPackageGraph(t *testing.T) {
+	// arrange
+	pkg := Package{
+		Path:      "example.com/store",
+		Types:     []Type{{Name: "file", Kind: "struct", Methods: []string{"file.Read"}}},
+		Functions: []Function{{Name: "Open", Exported: true, CalledFrom: []string{"example.com/app"}}, {Name: "file.Read", Exported: true}},
+		Calls:     []Call{{From: "Open", To: "file.Read"}},
+	}
+
+	// act
+	g := pkg.Graph()
+
+	// assert
+	assert.Equal(t, []string{"file", "Open", "file.Read", "example.com/app"}, nodeIDs(g.Nodes))
+	assert.Len(t, g.Edges, 3)
+	assert.Equal(t, "filled", g.Nodes[1].Attrs["style"])
+	assert.Equal(t, "called from:\nexample.com/app", g.Nodes[1].Attrs["tooltip"])
+}
+
+func nodeIDs(nodes []graph.Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func TestDeclName(t *testing.T) {
+	// arrange
+	file, err := parser.ParseFile(token.NewFileSet(), "store.go", `package store
+
+func Open() {}
+
+func (f *file[K]) Read() {}
+`, 0)
+	assert.NoError(t, err)
+
+	// act
+	names := []string{DeclName(file.Decls[0].(*ast.FuncDecl)), DeclName(file.Decls[1].(*ast.FuncDecl))}
+
+	// assert
+	assert.Equal(t, []string{"Open", "file.Read"}, names)
+}
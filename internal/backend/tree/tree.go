@@ -50,7 +50,7 @@ func BuildTree(path string, withHidden bool) (Node, error) {
 			}
 
 			childPath := filepath.Join(path, entry.Name())
-			childNode, err := BuildTree(childPath, false)
+			childNode, err := BuildTree(childPath, withHidden)
 			if err != nil {
 				return Node{}, fmt.Errorf("build tree for path '%s': %w", childPath, err)
 			}
@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// pageVariant is how the page differs from the default one.
+type pageVariant struct {
+	// collapse are directories merged into aggregate nodes.
+	collapse []string
+	// layers are dependencies outside the module shown on the graph, see layerStd and layerModules.
+	layers []string
+}
+
+// parseVariant reads 'collapse' and 'layer' query parameters, false is returned without them.
+// Directories collapsed by default stay collapsed without 'collapse' parameters, empty one expands them.
+func parseVariant(query url.Values, collapsed []string) (pageVariant, bool) {
+	collapseQuery, hasCollapse := query["collapse"]
+	layerQuery := query["layer"]
+	if !hasCollapse && len(layerQuery) == 0 {
+		return pageVariant{}, false
+	}
+
+	variant := pageVariant{collapse: collapsed}
+	if hasCollapse {
+		variant.collapse = nil
+		for _, dir := range collapseQuery {
+			if dir != "" {
+				variant.collapse = append(variant.collapse, dir)
+			}
+		}
+	}
+
+	for _, layer := range layerQuery {
+		if (layer == layerStd || layer == layerModules) && !slices.Contains(variant.layers, layer) {
+			variant.layers = append(variant.layers, layer)
+		}
+	}
+
+	return variant, true
+}
+
+// variantPage composes the page with the graph rendered for the variant.
+func (s snapshot) variantPage(ctx context.Context, variant pageVariant) ([]byte, error) {
+	// Only the image is available when goda output wasn't parsed.
+	if len(s.views.decorated.Nodes) == 0 {
+		return s.page, nil
+	}
+
+	variantGraph := s.views.decorated
+	if len(variant.layers) > 0 {
+		variantGraph = withLayers(variantGraph, s.views.modules, variant.layers)
+	}
+	if len(variant.collapse) > 0 {
+		variantGraph = collapsedGraph(variantGraph, s.views.modulePath, variant.collapse)
+	}
+
+	depsSVG, err := renderDepsGraph(ctx, s.renderer, variantGraph.DOT())
+	if err != nil {
+		return nil, fmt.Errorf("render page variant graph: %w", err)
+	}
+
+	settings := s.settings
+	settings.Collapsed = variant.collapse
+	settings.Layers = variant.layers
+
+	return composeHTML(s.views.treeHTML, depsSVG, settings)
+}
@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVariant(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  pageVariant
+		isSet bool
+	}{
+		{name: "default page", query: ""},
+		{name: "collapsed", query: "collapse=internal&collapse=cmd", want: pageVariant{collapse: []string{"internal", "cmd"}}, isSet: true},
+		{name: "expanded", query: "collapse=", isSet: true},
+		{name: "layers keep default collapse", query: "layer=std&layer=std&layer=unknown", want: pageVariant{collapse: []string{"pkg"}, layers: []string{"std"}}, isSet: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			query, err := url.ParseQuery(tt.query)
+			assert.NoError(t, err)
+
+			// act
+			got, ok := parseVariant(query, []string{"pkg"})
+
+			// assert
+			assert.Equal(t, tt.isSet, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexuserid/go-codevis/internal/backend/rules"
+	"github.com/alexuserid/go-codevis/internal/backend/tree"
+)
+
+const (
+	watchPollInterval = 500 * time.Millisecond
+	// watchDebounce is a quiet period after the last change before rebuild.
+	// Editors and formatters write several files in a row.
+	watchDebounce = time.Second
+)
+
+// fileStamp identifies version of a file.
+type fileStamp struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// watch polls the module directory and calls onChange with the new tree once changes settle.
+// The rules file is watched too, it may be outside of the module directory.
+func watch(ctx context.Context, withHidden bool, rulesFile string, onChange func(dirTree tree.Node)) {
+	log.Println("watch source changes")
+
+	stamps, err := watchedStamps(".", withHidden, rulesFile)
+	if err != nil {
+		log.Println("watch: collect file stamps failed: ", err)
+	}
+
+	var (
+		pending    bool
+		lastChange time.Time
+	)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		newStamps, err := watchedStamps(".", withHidden, rulesFile)
+		if err != nil {
+			// Files may disappear in the middle of walk, try next time.
+			log.Println("watch: collect file stamps failed: ", err)
+			continue
+		}
+
+		if !maps.Equal(stamps, newStamps) {
+			stamps = newStamps
+			pending = true
+			lastChange = time.Now()
+			continue
+		}
+
+		if pending && time.Since(lastChange) >= watchDebounce {
+			dirTree, err := tree.BuildTree(".", withHidden)
+			if err != nil {
+				log.Println("watch: build tree failed: ", err)
+				continue
+			}
+
+			pending = false
+
+			log.Println("sources changed. rebuild page")
+			onChange(dirTree)
+		}
+	}
+}
+
+// watchedStamps collects stamps of files under root which affect the page and of the rules file.
+// Other files are stamped by size only, as the tree shows them with sizes.
+// Empty rules file means rules.DefaultFile. '.git' is skipped even with hidden files, git changes it constantly.
+func watchedStamps(root string, withHidden bool, rulesFile string) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+		if path != root && (name == ".git" || !withHidden && strings.HasPrefix(name, ".")) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		stamp := fileStamp{Size: info.Size()}
+		if isWatchedFile(name) {
+			stamp.ModTime = info.ModTime()
+		}
+		stamps[path] = stamp
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk '%s': %w", root, err)
+	}
+
+	if rulesFile == "" {
+		rulesFile = filepath.Join(root, rules.DefaultFile)
+	}
+
+	// Missing rules file is optional, its creation is a change.
+	if info, err := os.Stat(rulesFile); err == nil {
+		stamps[rulesFile] = fileStamp{Size: info.Size(), ModTime: info.ModTime()}
+	}
+
+	return stamps, nil
+}
+
+func isWatchedFile(name string) bool {
+	return strings.HasSuffix(name, ".go") || name == "go.mod" || name == "go.sum"
+}
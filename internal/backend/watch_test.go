@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchedStamps(t *testing.T) {
+	// arrange
+	root := t.TempDir()
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	for file, content := range map[string]string{
+		"README.md":             "readme",
+		"go.mod":                "module example.com/app",
+		"internal/worker.go":    "package internal",
+		"internal/worker.proto": "syntax",
+		".git/index.go":         "git",
+		".hidden/hidden.go":     "package hidden",
+		".codevis.yaml":         "rules: []",
+	} {
+		path := filepath.Join(root, file)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	assert.NoError(t, os.WriteFile(rulesFile, []byte("rules: []"), 0o644))
+
+	// act
+	defaultRules, err := watchedStamps(root, false, "")
+	assert.NoError(t, err)
+	withHidden, err := watchedStamps(root, true, rulesFile)
+	assert.NoError(t, err)
+
+	// assert
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, ".codevis.yaml"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "go.mod"),
+		filepath.Join(root, "internal/worker.go"),
+		filepath.Join(root, "internal/worker.proto"),
+	}, slices.Collect(maps.Keys(defaultRules)))
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, ".codevis.yaml"),
+		filepath.Join(root, ".hidden/hidden.go"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "go.mod"),
+		filepath.Join(root, "internal/worker.go"),
+		filepath.Join(root, "internal/worker.proto"),
+		rulesFile,
+	}, slices.Collect(maps.Keys(withHidden)), "'.git' is skipped with hidden files, configured rules file is watched")
+
+	assert.Equal(t, fileStamp{Size: 6}, defaultRules[filepath.Join(root, "README.md")], "other files are stamped by size")
+	assert.NotZero(t, defaultRules[filepath.Join(root, "go.mod")].ModTime)
+	assert.NotZero(t, defaultRules[filepath.Join(root, ".codevis.yaml")].ModTime, "rules file is stamped by time")
+}
@@ -10,3 +10,6 @@ var Style string
 
 //go:embed index.html
 var BasicHTML string
+
+//go:embed source.html
+var SourceHTML string
@@ -15,10 +15,21 @@ class SVGMarker {
 
   init() {
     this.createMarkToggles();
+
+    // Marks survive graph swaps by package paths.
+    let markedTitles = [];
+    this.svg.addEventListener("beforegraphchange", () => {
+      markedTitles = this.markedTitles();
+    });
+    this.svg.addEventListener("graphchange", () => {
+      this.marked.clear();
+      this.createMarkToggles();
+      this.markByTitles(markedTitles);
+    });
   }
 
   createMarkToggles() {
-    const graphNodes = document.getElementsByClassName("node");
+    const graphNodes = this.svg.getElementsByClassName("node");
     for (var i = 0; i < graphNodes.length; i++) {
       const nodeID = graphNodes[i].id;
 
@@ -57,6 +68,7 @@ class SVGMarker {
     }
 
     this.marked.set(graphNode.id, nodeEdges);
+    this.svg.dispatchEvent(new CustomEvent("markchange"));
   }
 
   unsetMarks(graphNode) {
@@ -68,6 +80,38 @@ class SVGMarker {
     }
 
     this.marked.delete(graphNode.id);
+    this.svg.dispatchEvent(new CustomEvent("markchange"));
+  }
+
+  clearMarks() {
+    for (const nodeID of [...this.marked.keys()]) {
+      this.unsetMarks(document.getElementById(nodeID));
+    }
+  }
+
+  // Node ids change between graph renders, titles (package paths) don't.
+  nodeTitle(graphNode) {
+    return graphNode.getElementsByTagName("title")[0].textContent;
+  }
+
+  markedTitles() {
+    let titles = [];
+    for (const nodeID of this.marked.keys()) {
+      titles.push(this.nodeTitle(document.getElementById(nodeID)));
+    }
+    return titles;
+  }
+
+  markByTitles(titles) {
+    const graphNodes = document.getElementsByClassName("node");
+    for (var i = 0; i < graphNodes.length; i++) {
+      if (
+        titles.includes(this.nodeTitle(graphNodes[i])) &&
+        !this.markedNode(graphNodes[i])
+      ) {
+        this.setMarks(graphNodes[i]);
+      }
+    }
   }
 
   findNodeEdges(graphNode) {
@@ -104,6 +148,8 @@ class SVGViewController {
     this.slowZoomFactor = 1.05;
     this.zoomLevel = 0;
     this.animationDuration = options.animationDuration || 300;
+    // Keyboard and zoom buttons of the page control the main graph only.
+    this.bindControls = options.bindControls ?? true;
 
     this.init();
   }
@@ -119,6 +165,32 @@ class SVGViewController {
   }
 
   init() {
+    if (this.bindControls) {
+      this.initControls();
+    }
+
+    // Mouse pan
+    this.container.addEventListener("mousedown", this.startPan.bind(this));
+    document.addEventListener("mousemove", this.doPan.bind(this));
+    document.addEventListener("mouseup", this.endPan.bind(this));
+    document.addEventListener("mouseleave", this.endPan.bind(this));
+
+    // Mouse wheel pan
+    this.container.addEventListener("wheel", this.handleWheel.bind(this), {
+      passive: false,
+    });
+
+    // Wheel zoom
+    this.container.addEventListener("DOMMouseScroll", this.handleScroll, false); // for Firefox
+    this.container.addEventListener("mousewheel", this.handleScroll, false); // for everyone else
+
+    // Cursor change on pan
+    this.svg.style.cursor = "grab";
+
+    this.svg.addEventListener("graphchange", () => this.resetGraph());
+  }
+
+  initControls() {
     // Handle keyboard: + and -
     document.addEventListener("keydown", (e) => {
       // + and = are on the same key
@@ -140,24 +212,6 @@ class SVGViewController {
     document
       .getElementById("zoomOut")
       ?.addEventListener("click", () => this.zoomOut());
-
-    // Mouse pan
-    this.container.addEventListener("mousedown", this.startPan.bind(this));
-    document.addEventListener("mousemove", this.doPan.bind(this));
-    document.addEventListener("mouseup", this.endPan.bind(this));
-    document.addEventListener("mouseleave", this.endPan.bind(this));
-
-    // Mouse wheel pan
-    this.container.addEventListener("wheel", this.handleWheel.bind(this), {
-      passive: false,
-    });
-
-    // Wheel zoom
-    this.container.addEventListener("DOMMouseScroll", this.handleScroll, false); // for Firefox
-    this.container.addEventListener("mousewheel", this.handleScroll, false); // for everyone else
-
-    // Cursor change on pan
-    this.svg.style.cursor = "grab";
   }
 
   startPan(e) {
@@ -348,28 +402,72 @@ class SVGViewController {
     });
   }
 
+  setViewBox(viewBox) {
+    this.currentViewBox = { ...viewBox };
+    this.updateViewBox();
+  }
+
+  // Graph swapped in place has its own size.
+  resetGraph() {
+    this.initialViewBox = this.parseViewBox(this.svg.getAttribute("viewBox"));
+    this.resetZoom();
+  }
+
   updateViewBox() {
     const { x, y, width, height } = this.currentViewBox;
     this.svg.setAttribute("viewBox", `${x} ${y} ${width} ${height}`);
+    this.svg.dispatchEvent(new CustomEvent("viewboxchange"));
   }
 }
 
 class CallvisIncluder {
-  constructor(options = {}) {
+  constructor(svgElement, callGraphOptions, panel, options = {}) {
+    this.svg = svgElement;
+    this.callGraphOptions = callGraphOptions;
+    this.panel = panel;
+    this.entries = []; // [{element, gopkgPath}]
+    this.rootPkg = document
+      .getElementById("tree-container")
+      .getElementsByClassName("root")[0].id;
+    // Exported report has pre-rendered call graphs instead of server.
+    this.staticCallvis = codevisSettings.staticCallvis;
+
     this.init();
   }
 
   init() {
-    const rootPkg = document
-      .getElementById("tree-container")
-      .getElementsByClassName("root")[0].id;
+    this.panel.resolve = (gopkgPath) => this.callGraphOf(gopkgPath);
+    this.addEntries();
 
-    const graphNodes = document.getElementsByClassName("node");
+    if (!this.staticCallvis) {
+      this.callGraphOptions.onChange(() => this.updateVisibility());
+      this.updateVisibility();
+    }
+
+    this.svg.addEventListener("graphchange", () => {
+      this.entries = [];
+      this.addEntries();
+      if (!this.staticCallvis) {
+        this.updateVisibility();
+      }
+    });
+  }
+
+  addEntries() {
+    const staticCallvis = this.staticCallvis;
+    const graphNodes = this.svg.getElementsByClassName("node");
     for (var i = 0; i < graphNodes.length; i++) {
       const nodeID = graphNodes[i].id;
 
       let textElements = graphNodes[i].getElementsByTagName("text");
-      if (textElements.length == 0) {
+      if (textElements.length == 0 || !isPackageNode(graphNodes[i])) {
+        continue;
+      }
+
+      const gopkgPath =
+        textElements[0].parentElement.getAttribute("xlink:title");
+
+      if (staticCallvis && !staticCallvis[gopkgPath]) {
         continue;
       }
 
@@ -384,98 +482,1952 @@ class CallvisIncluder {
       callvisEntry.setAttribute("font-size", "12.00");
       callvisEntry.innerHTML = "c";
 
-      const gopkgPath =
-        textElements[0].parentElement.getAttribute("xlink:title");
-      const callvisURL =
-        "http://localhost:9798/callvis?limit=" + rootPkg + "&f=" + gopkgPath;
-
+      // Resolve on click, options may change.
       callvisEntry.addEventListener("click", () =>
-        this.callvisCall(callvisURL),
+        this.panel.open(this.callGraphOf(gopkgPath)),
       );
 
       graphNodes[i].appendChild(callvisEntry);
+      this.entries.push({ element: callvisEntry, gopkgPath: gopkgPath });
     }
   }
 
-  callvisCall(callvisURL) {
-    window.open(callvisURL, "_blank");
+  // Packages outside the module have no package call graph.
+  updateVisibility() {
+    const packageRoot = this.callGraphOptions.params().root == "package";
+    for (const entry of this.entries) {
+      entry.element.style.display =
+        packageRoot && !inModule(entry.gopkgPath) ? "none" : "";
+    }
   }
-}
 
-// Need to zoom to the same scale for any svg size.
-function calculateZoomFactor(svg) {
-  const svgWidth = svg.getBBox().width;
-  // Found 0.0008 empirically
-  let factor = svgWidth * 0.0008;
-  if (factor < 1) {
-    factor = 1;
+  // Call graph is {pkgPath, url, doc, title}, where doc is set for exported documents.
+  callGraphOf(gopkgPath) {
+    if (this.staticCallvis) {
+      const doc = this.staticCallvis[gopkgPath];
+      return doc ? { pkgPath: gopkgPath, doc: doc } : null;
+    }
+
+    return {
+      pkgPath: gopkgPath,
+      url: callvisPageURL({
+        limit: this.rootPkg,
+        f: gopkgPath,
+        ...this.callGraphOptions.params(),
+      }),
+    };
   }
-  return factor;
 }
 
-// Init after DOM loaded
-document.addEventListener("DOMContentLoaded", () => {
-  // Init zoomer
-  const svg = document.getElementById("svg");
-  const container = document.getElementById("svgContainer");
-  const viewController = new SVGViewController(svg, container, {
-    zoomFactor: 1.5,
-    zoomElementFactor: calculateZoomFactor(svg),
-    animationDuration: 300,
-  });
+// Shows call graphs and package structure inside the page with history
+// of visited packages and breadcrumb from module to package to function.
+class CallvisPanel {
+  constructor(element, options = {}) {
+    this.element = element;
+    this.body = element.querySelector(".callvis-panel-body");
+    this.breadcrumb = element.querySelector(".callvis-panel-breadcrumb");
+    this.info = element.querySelector(".callvis-panel-info");
+    this.history = []; // [call graph]
+    this.position = -1;
+    this.viewController = null;
+    // Returns call graph of package path, set by CallvisIncluder.
+    this.resolve = () => null;
+    // Adds actions to nodes of shown graphs, set by EditorOpener.
+    this.onGraphNode = () => {};
+
+    this.init();
+  }
+
+  init() {
+    const on = (className, action) =>
+      this.element
+        .querySelector("." + className)
+        .addEventListener("click", action);
+
+    on("callvis-back", () => this.go(-1));
+    on("callvis-forward", () => this.go(1));
+    on("callvis-close", () => this.close());
+    on("callvis-new-tab", () => this.openInNewTab());
+    on("callvis-reset", () => this.viewController?.resetZoom());
+    on("callvis-zoom-in", () => this.viewController?.zoomIn());
+    on("callvis-zoom-out", () => this.viewController?.zoomOut());
+
+    document.addEventListener("keydown", (e) => {
+      if (e.key == "Escape" && !this.element.hidden) {
+        this.close();
+      }
+    });
+  }
 
-  new CallvisIncluder();
+  open(callGraph) {
+    if (!callGraph) {
+      return;
+    }
 
-  new SVGMarker(svg, {});
+    // Opening from the middle of history drops forward entries like browsers do.
+    this.history = this.history.slice(0, this.position + 1);
+    this.history.push({ ...callGraph, function: null });
+    this.position = this.history.length - 1;
+    this.render();
+  }
 
-  // TODO: refactor code below.
+  go(step) {
+    const position = this.position + step;
+    if (position < 0 || position >= this.history.length) {
+      return;
+    }
+    this.position = position;
+    this.render();
+  }
 
-  // Gather all graph nodes to match them with tree nodes.
-  var graphNodes = document.querySelectorAll('g[class="node"]');
-  var nodeTitles = {};
-  for (var i = 0; i < graphNodes.length; i++) {
-    title = graphNodes[i].childNodes[1].innerHTML.replace(/"/g, "");
-    nodeID = graphNodes[i].id;
-    nodeTitles[title] = nodeID;
+  close() {
+    this.element.hidden = true;
   }
 
-  // Create map with [tree node id] graph node id
-  var anchors = document.getElementsByClassName("tree-entry");
-  var treeGraphMap = {};
-  for (var i = 0; i < anchors.length; i++) {
-    const anchor = anchors[i];
-    const relativePackagePath = anchor.id;
-    for (const packagePath in nodeTitles) {
-      if (packagePath.endsWith(relativePackagePath)) {
-        const nodeID = nodeTitles[packagePath];
-        treeGraphMap[relativePackagePath] = nodeID;
+  current() {
+    return this.history[this.position];
+  }
+
+  openInNewTab() {
+    const callGraph = this.current();
+    if (callGraph) {
+      window.open(callGraph.url || staticDocumentURL(callGraph.doc), "_blank");
+    }
+  }
+
+  async render() {
+    const callGraph = this.current();
+    this.element.hidden = false;
+    this.element.querySelector(".callvis-back").disabled = this.position == 0;
+    this.element.querySelector(".callvis-forward").disabled =
+      this.position == this.history.length - 1;
+    this.renderBreadcrumb();
+
+    // New element for every graph, so pan and zoom listeners go away with the old one.
+    const graphContainer = document.createElement("div");
+    graphContainer.className = "callvis-panel-graph";
+    graphContainer.textContent = "Loading...";
+    this.body.replaceChildren(graphContainer);
+    this.viewController = null;
+
+    let content;
+    try {
+      content = await this.load(callGraph);
+    } catch (err) {
+      graphContainer.textContent = `Failed to load call graph: ${err.message}`;
+      return;
+    }
+
+    // Another graph may be opened while this one was loading.
+    if (callGraph != this.current()) {
+      return;
+    }
+
+    this.show(graphContainer, content);
+  }
+
+  async load(callGraph) {
+    if (callGraph.doc?.content) {
+      return callGraph.doc.content;
+    }
+
+    const response = await fetch(callGraph.url || callGraph.doc.url);
+    const text = await response.text();
+    if (!response.ok) {
+      throw new Error(text);
+    }
+    return text;
+  }
+
+  show(graphContainer, content) {
+    const doc = new DOMParser().parseFromString(content, "text/html");
+    const header = doc.querySelector(".callvis-header");
+    this.info.textContent = header
+      ? header.textContent
+      : this.current().title || "";
+
+    const svg = doc.querySelector("svg");
+    if (!svg) {
+      graphContainer.textContent = "Call graph is empty";
+      return;
+    }
+
+    const graph = document.importNode(svg, true);
+    graph.removeAttribute("width");
+    graph.removeAttribute("height");
+    graphContainer.replaceChildren(graph);
+
+    this.viewController = new SVGViewController(graph, graphContainer, {
+      bindControls: false,
+    });
+    this.interceptLinks(graph);
+  }
 
-        break;
+  // Package links are opened in the panel, click on a node selects the function.
+  interceptLinks(graph) {
+    for (const link of graph.querySelectorAll("a")) {
+      const href = link.getAttribute("xlink:href") || link.getAttribute("href");
+      if (!href) {
+        continue;
       }
+
+      const url = new URL(href, window.location.origin);
+      link.addEventListener("click", (e) => {
+        e.preventDefault();
+
+        const target = this.linkTarget(url);
+        if (target) {
+          e.stopPropagation();
+          this.open(target);
+        }
+      });
+    }
+
+    for (const graphNode of graph.querySelectorAll(".node")) {
+      graphNode.addEventListener("click", () =>
+        this.selectFunction(graph, graphNode),
+      );
+      // Jump to definition.
+      graphNode.addEventListener("dblclick", () => this.openFunctionSource());
+      this.onGraphNode(graphNode);
     }
   }
 
-  // Set element tree click action - zoom and highlight
-  var anchors = document.getElementsByClassName("tree-entry");
-  for (var i = 0; i < anchors.length; i++) {
-    const anchor = anchors[i];
-    anchor.onclick = function () {
-      const graphNodeID = treeGraphMap[anchor.id];
+  openFunctionSource() {
+    const callGraph = this.current();
+    if (!codevisSettings.source || !callGraph.function) {
+      return;
+    }
 
-      const graphNode = document.getElementById(graphNodeID);
-      if (!graphNode) {
-        return;
+    window.open(
+      sourcePageURL({ pkg: callGraph.pkgPath, func: callGraph.function }),
+      "_blank",
+    );
+  }
+
+  // go-callvis links focus other packages, structure links packages calling functions.
+  linkTarget(url) {
+    const focus = url.searchParams.get("f");
+    if (focus) {
+      return focus != this.current().pkgPath ? this.resolve(focus) : null;
+    }
+
+    if (url.pathname == "/structure") {
+      return structureOf(url.searchParams.get("path"));
+    }
+
+    return null;
+  }
+
+  selectFunction(graph, graphNode) {
+    for (const marked of graph.querySelectorAll(".marked-node")) {
+      marked.classList.remove("marked-node");
+    }
+    graphNode.classList.add("marked-node");
+
+    this.current().function = nodeTitle(graphNode);
+    this.renderBreadcrumb();
+  }
+
+  renderBreadcrumb() {
+    const callGraph = this.current();
+    const crumbs = [
+      { text: codevisSettings.modulePath, onClick: () => this.close() },
+      {
+        text: shortPackagePath(callGraph.pkgPath),
+        onClick: () => this.render(),
+      },
+    ];
+    if (callGraph.function) {
+      crumbs.push({
+        text: callGraph.function,
+        onClick: codevisSettings.source
+          ? () => this.openFunctionSource()
+          : null,
+      });
+    }
+
+    this.breadcrumb.replaceChildren();
+    crumbs.forEach((crumb, i) => {
+      if (i > 0) {
+        this.breadcrumb.append(" › ");
       }
-      viewController.zoomToElement(graphNode);
+      const item = document.createElement("span");
+      item.textContent = crumb.text;
+      if (crumb.onClick) {
+        item.className = "callvis-crumb";
+        item.addEventListener("click", crumb.onClick);
+      }
+      this.breadcrumb.appendChild(item);
+    });
+  }
+}
 
-      const polygon = graphNode.getElementsByTagName("polygon")[0];
-      polygon.setAttribute("fill", "#FACDEE");
+// Chooses what call graphs are rooted at: a main package, the clicked package
+// without roots or module tests, and the call graph algorithm.
+class CallGraphOptions {
+  constructor(element, algoElement, mainPackages, defaults, options = {}) {
+    this.element = element;
+    this.algoElement = algoElement;
+    this.mainPackages = mainPackages || [];
+    this.defaultMain = defaults.main;
+    this.defaultAlgo = defaults.algo;
 
-      const text = graphNode.getElementsByTagName("text")[0];
+    this.init();
+  }
 
-      setTimeout(() => {
-        polygon.setAttribute("fill", "none");
-      }, "3000");
-    };
+  init() {
+    if (this.defaultAlgo) {
+      this.algoElement.value = this.defaultAlgo;
+    }
+    this.algoElement.hidden = false;
+
+    for (const mainPkg of this.mainPackages) {
+      this.addOption(`main:${mainPkg}`, `main: ${mainPkg}`);
+    }
+    this.addOption("package", "package, unrooted");
+    this.addOption("tests", "module tests");
+
+    this.element.value = this.defaultMain
+      ? `main:${this.defaultMain}`
+      : "package";
+    this.element.hidden = false;
+  }
+
+  addOption(value, text) {
+    const option = document.createElement("option");
+    option.value = value;
+    option.textContent = text;
+    this.element.appendChild(option);
+  }
+
+  onChange(callback) {
+    this.element.addEventListener("change", callback);
+  }
+
+  params() {
+    const [root, mainPkg] = this.element.value.split(":");
+    const params = { root: root, algo: this.algoElement.value };
+    if (root == "main") {
+      params.main = mainPkg;
+    }
+    return params;
+  }
+}
+
+// Reloads the page when the server rebuilds it, keeping zoom and marked nodes.
+class LiveReloader {
+  constructor(viewState, options = {}) {
+    this.viewState = viewState;
+
+    this.init();
+  }
+
+  init() {
+    const events = new EventSource("/events");
+    events.addEventListener("update", () => {
+      // The view is restored from the URL hash after reload.
+      this.viewState.save();
+      window.location.reload();
+    });
+  }
+}
+
+// Overlay next to the graph listing analysis results.
+class SidePanel {
+  constructor(element, options = {}) {
+    this.element = element;
+  }
+
+  // Items are {text, title, onClick}.
+  addSection(heading, items) {
+    const section = document.createElement("section");
+
+    const header = document.createElement("h3");
+    header.textContent = heading;
+    section.appendChild(header);
+
+    const list = document.createElement("ul");
+    for (const item of items) {
+      const entry = document.createElement("li");
+      entry.textContent = item.text;
+      entry.title = item.title || "";
+      if (item.onClick) {
+        entry.classList.add("side-panel-entry");
+        entry.addEventListener("click", item.onClick);
+      }
+      list.appendChild(entry);
+    }
+    section.appendChild(list);
+
+    this.element.appendChild(section);
+    this.element.hidden = false;
+
+    return section;
+  }
+}
+
+// Lists imports breaking architecture rules, click zooms to the importing package.
+class ViolationsPanel {
+  constructor(sidePanel, viewController, violations, options = {}) {
+    this.sidePanel = sidePanel;
+    this.viewController = viewController;
+    this.violations = violations;
+
+    this.init();
+  }
+
+  init() {
+    const items = this.violations.map((violation) => ({
+      text: `${shortPackagePath(violation.from)} → ${shortPackagePath(violation.to)}`,
+      title: `${violation.rule}: ${violation.reason}`,
+      onClick: () => {
+        const graphNode = graphNodeByTitle(violation.from);
+        if (graphNode) {
+          this.viewController.zoomToElement(graphNode);
+        }
+      },
+    }));
+
+    this.sidePanel.addSection(
+      `Rule violations (${this.violations.length})`,
+      items,
+    );
+  }
+}
+
+// Lists import cycles between directories, click marks packages of the cycle.
+class CyclesPanel {
+  constructor(sidePanel, viewController, marker, cycles, options = {}) {
+    this.sidePanel = sidePanel;
+    this.viewController = viewController;
+    this.marker = marker;
+    this.cycles = cycles;
+
+    this.init();
+  }
+
+  init() {
+    const items = this.cycles.map((cycle) => ({
+      text: cycle.groups.map(shortPackagePath).join(" ↔ "),
+      title: cycle.imports
+        .map((imp) => `${shortPackagePath(imp.from)} → ${shortPackagePath(imp.to)}${imp.test ? " (test)" : ""}`)
+        .join("\n"),
+      onClick: () => this.show(cycle),
+    }));
+
+    this.sidePanel.addSection(`Import cycles (${this.cycles.length})`, items);
+  }
+
+  show(cycle) {
+    const packages = new Set();
+    for (const imp of cycle.imports) {
+      packages.add(imp.from);
+      packages.add(imp.to);
+    }
+
+    this.marker.markByTitles([...packages]);
+
+    const first = graphNodeByTitle(cycle.imports[0]?.from);
+    if (first) {
+      this.viewController.zoomToElement(first);
+    }
+  }
+}
+
+// Lists package graph changes between revisions.
+class DiffPanel {
+  constructor(sidePanel, viewController, diff, options = {}) {
+    this.sidePanel = sidePanel;
+    this.viewController = viewController;
+    this.diff = diff;
+
+    this.init();
+  }
+
+  init() {
+    const packageItem = (pkgPath) => ({
+      text: shortPackagePath(pkgPath),
+      title: pkgPath,
+      onClick: () => this.zoomTo(pkgPath),
+    });
+    const importItem = (imp) => ({
+      text: `${shortPackagePath(imp.from)} → ${shortPackagePath(imp.to)}`,
+      title: `${imp.from} → ${imp.to}`,
+      onClick: () => this.zoomTo(imp.from),
+    });
+
+    const diff = this.diff;
+    this.sidePanel.addSection(`Diff ${diff.base}..${diff.head}`, []);
+    this.addNonEmpty("New dependencies", diff.addedImports.map(importItem));
+    this.addNonEmpty("Removed dependencies", diff.removedImports.map(importItem));
+    this.addNonEmpty("New packages", diff.addedPackages.map(packageItem));
+    this.addNonEmpty("Removed packages", diff.removedPackages.map(packageItem));
+  }
+
+  addNonEmpty(heading, items) {
+    if (items.length > 0) {
+      this.sidePanel.addSection(`${heading} (${items.length})`, items);
+    }
+  }
+
+  zoomTo(pkgPath) {
+    const graphNode = graphNodeByTitle(pkgPath);
+    if (graphNode) {
+      this.viewController.zoomToElement(graphNode);
+    }
+  }
+}
+
+// Shows package metrics in node tooltips and encodes the chosen metric as node color.
+class MetricsOverlay {
+  constructor(svgElement, metrics, options = {}) {
+    this.svg = svgElement;
+    this.metrics = metrics;
+    this.tooltip = document.getElementById("metrics-tooltip");
+    this.colorSelect = document.getElementById("metrics-color");
+    this.originalFills = new Map(); // map[nodeID]fill
+
+    this.init();
+  }
+
+  init() {
+    this.bindNodes();
+
+    this.colorSelect.hidden = false;
+    this.colorSelect.addEventListener("change", () =>
+      this.colorBy(this.colorSelect.value),
+    );
+
+    this.svg.addEventListener("graphchange", () => {
+      this.originalFills.clear();
+      this.bindNodes();
+      this.colorBy(this.colorSelect.value);
+    });
+  }
+
+  bindNodes() {
+    const graphNodes = this.svg.getElementsByClassName("node");
+    for (var i = 0; i < graphNodes.length; i++) {
+      const graphNode = graphNodes[i];
+      const metrics = this.metrics[nodeTitle(graphNode)];
+      if (!metrics) {
+        continue;
+      }
+
+      graphNode.addEventListener("mouseenter", (e) =>
+        this.showTooltip(metrics, e),
+      );
+      graphNode.addEventListener("mousemove", (e) => this.moveTooltip(e));
+      graphNode.addEventListener("mouseleave", () => this.hideTooltip());
+    }
+  }
+
+  showTooltip(metrics, e) {
+    this.tooltip.textContent = [
+      shortPackagePath(metrics.path),
+      `Ca ${metrics.ca}, Ce ${metrics.ce}`,
+      `instability ${metrics.instability.toFixed(2)}`,
+      `abstractness ${metrics.abstractness.toFixed(2)}`,
+      `${metrics.loc} loc, ${metrics.files} files`,
+      `${metrics.exported} exported`,
+    ].join("\n");
+    this.tooltip.hidden = false;
+    this.moveTooltip(e);
+  }
+
+  moveTooltip(e) {
+    this.tooltip.style.left = `${e.clientX + 15}px`;
+    this.tooltip.style.top = `${e.clientY + 15}px`;
+  }
+
+  hideTooltip() {
+    this.tooltip.hidden = true;
+  }
+
+  // Metric value is scaled to [0, 1] and colored from green to red.
+  colorBy(metricName) {
+    let maxValue = 0;
+    for (const metrics of Object.values(this.metrics)) {
+      maxValue = Math.max(maxValue, metrics[metricName] || 0);
+    }
+
+    const graphNodes = this.svg.getElementsByClassName("node");
+    for (var i = 0; i < graphNodes.length; i++) {
+      const shape = graphNodes[i].querySelector("polygon, path");
+      if (!shape) {
+        continue;
+      }
+
+      if (!this.originalFills.has(graphNodes[i].id)) {
+        this.originalFills.set(graphNodes[i].id, unflashedFill(shape));
+      }
+
+      const metrics = this.metrics[nodeTitle(graphNodes[i])];
+      if (!metrics || metricName == "none" || maxValue == 0) {
+        shape.setAttribute("fill", this.originalFills.get(graphNodes[i].id));
+        continue;
+      }
+
+      const value = metrics[metricName] / maxValue;
+      shape.setAttribute("fill", `hsl(${120 * (1 - value)}, 70%, 80%)`);
+    }
+  }
+}
+
+// Right click opens packages, files and functions in the local editor.
+// Left click keeps zooming and marking.
+class EditorOpener {
+  constructor(svgElement, panel, options = {}) {
+    this.svg = svgElement;
+    this.panel = panel;
+
+    this.init();
+  }
+
+  init() {
+    const treeEntries = document.querySelectorAll(
+      "#tree-container .root, #tree-container .gopkg, #tree-container .gofile",
+    );
+    for (const entry of treeEntries) {
+      // Root id is absolute path, others are relative to the module root.
+      const path = entry.classList.contains("root") ? "." : entry.id;
+      this.onContextMenu(entry, () => this.open({ path: path }));
+    }
+
+    this.bindNodes();
+    this.svg.addEventListener("graphchange", () => this.bindNodes());
+
+    this.panel.onGraphNode = (graphNode) =>
+      this.onContextMenu(graphNode, () =>
+        this.open({
+          pkg: this.panel.current().pkgPath,
+          func: nodeTitle(graphNode),
+        }),
+      );
+  }
+
+  bindNodes() {
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      if (!isPackageNode(graphNode)) {
+        continue;
+      }
+
+      this.onContextMenu(graphNode, () =>
+        this.open({ pkg: nodeTitle(graphNode) }),
+      );
+    }
+  }
+
+  onContextMenu(element, action) {
+    element.addEventListener("contextmenu", (e) => {
+      e.preventDefault();
+      action();
+    });
+  }
+
+  async open(target) {
+    try {
+      const response = await fetch("/api/open", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(target),
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      // URI templates are opened by the browser, e.g. 'vscode://file/...'.
+      const result = await response.json();
+      if (result.uri) {
+        window.location.href = result.uri;
+      }
+    } catch (err) {
+      // E.g. terminal editor from $EDITOR, the server can't run it.
+      alert(`Open in editor failed: ${err.message}`);
+    }
+  }
+}
+
+// Searches packages, files and symbols on the server, '/' focuses the search box.
+// Selected result zooms to the package node.
+class SearchBox {
+  constructor(element, viewController, options = {}) {
+    this.element = element;
+    this.input = element.querySelector("input");
+    this.list = element.querySelector(".search-results");
+    this.viewController = viewController;
+    this.results = [];
+    this.selected = -1;
+    this.timer = null;
+    this.requests = 0;
+
+    this.init();
+  }
+
+  init() {
+    this.element.hidden = false;
+
+    document.addEventListener("keydown", (e) => {
+      if (e.key == "/" && !isTyping(e.target)) {
+        e.preventDefault();
+        this.input.focus();
+        this.input.select();
+      }
+    });
+
+    this.input.addEventListener("input", () => {
+      clearTimeout(this.timer);
+      this.timer = setTimeout(() => this.search(), 150);
+    });
+
+    this.input.addEventListener("keydown", (e) => {
+      // Page shortcuts, e.g. zoom on '+' and '-', don't apply while typing.
+      e.stopPropagation();
+
+      switch (e.key) {
+        case "ArrowDown":
+          e.preventDefault();
+          this.highlight(this.selected + 1);
+          break;
+        case "ArrowUp":
+          e.preventDefault();
+          this.highlight(this.selected - 1);
+          break;
+        case "Enter":
+          if (this.results.length > 0) {
+            this.select(this.results[Math.max(this.selected, 0)]);
+          }
+          break;
+        case "Escape":
+          this.input.blur();
+          break;
+      }
+    });
+
+    this.input.addEventListener("focus", () => {
+      this.list.hidden = this.results.length == 0;
+    });
+    this.input.addEventListener("blur", () => {
+      this.list.hidden = true;
+    });
+  }
+
+  async search() {
+    const request = ++this.requests;
+    const query = this.input.value.trim();
+    if (!query) {
+      this.show([]);
+      return;
+    }
+
+    try {
+      const response = await fetch(
+        `/api/search?q=${encodeURIComponent(query)}`,
+      );
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      const results = await response.json();
+      // Responses of older queries may come after newer ones.
+      if (request == this.requests) {
+        this.show(results);
+      }
+    } catch (err) {
+      console.error("search failed:", err);
+    }
+  }
+
+  show(results) {
+    this.results = results;
+    this.selected = -1;
+    this.list.replaceChildren();
+
+    for (const result of results) {
+      const item = document.createElement("li");
+      item.className = "search-result";
+
+      const kind = document.createElement("span");
+      kind.className = "search-kind";
+      kind.textContent = result.kind;
+      item.append(kind, " ", result.name);
+
+      if (result.kind != "package") {
+        const location = document.createElement("span");
+        location.className = "search-package";
+        location.textContent = result.line
+          ? `${result.path}:${result.line}`
+          : shortPackagePath(result.package);
+        item.append(" ", location);
+      }
+
+      // Mousedown comes before blur hiding the list.
+      item.addEventListener("mousedown", (e) => {
+        e.preventDefault();
+        this.select(result);
+      });
+      this.list.appendChild(item);
+    }
+
+    this.list.hidden = results.length == 0;
+  }
+
+  highlight(index) {
+    const items = this.list.children;
+    if (items.length == 0) {
+      return;
+    }
+
+    this.selected = (index + items.length) % items.length;
+    for (var i = 0; i < items.length; i++) {
+      items[i].classList.toggle("selected", i == this.selected);
+    }
+    items[this.selected].scrollIntoView({ block: "nearest" });
+  }
+
+  select(result) {
+    this.input.blur();
+
+    const graphNode = graphNodeByTitle(result.package);
+    if (!graphNode) {
+      return;
+    }
+
+    this.viewController.zoomToElement(graphNode);
+    flashGraphNode(graphNode);
+  }
+}
+
+// Double-click on a package opens its types and functions in the panel.
+class StructureDrilldown {
+  constructor(svgElement, panel) {
+    this.svg = svgElement;
+    this.panel = panel;
+
+    this.init();
+  }
+
+  init() {
+    this.bindNodes();
+    this.svg.addEventListener("graphchange", () => this.bindNodes());
+  }
+
+  bindNodes() {
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      if (!isPackageNode(graphNode)) {
+        continue;
+      }
+
+      graphNode.addEventListener("dblclick", (e) => {
+        e.preventDefault();
+        this.panel.open(structureOf(nodeTitle(graphNode)));
+      });
+    }
+  }
+}
+
+function nodeTitle(graphNode) {
+  return graphNode.getElementsByTagName("title")[0].textContent;
+}
+
+// Collapsed directories are rendered as nodes titled like 'example.com/app/internal/...'.
+function isAggregateNode(graphNode) {
+  return nodeTitle(graphNode).endsWith("/...");
+}
+
+// Module layers are rendered as nodes titled 'std' or like 'golang.org/x/text@v0.14.0'.
+function isLayerNode(graphNode) {
+  const title = nodeTitle(graphNode);
+  return title == "std" || title.includes("@");
+}
+
+// Only package nodes have call graphs, structure and source.
+function isPackageNode(graphNode) {
+  return !isAggregateNode(graphNode) && !isLayerNode(graphNode);
+}
+
+// TreeView folds directories of the tree and shows or hides file leaves.
+// On the served page folding a directory also collapses its packages in the graph
+// into one node, the page is reloaded with 'collapse' query then. Double-click
+// on the node or unfolding the directory expands it back.
+class TreeView {
+  constructor(container, svg) {
+    this.tree = container.querySelector(".tree");
+    this.svg = svg;
+    this.filesToggle = document.getElementById("tree-files");
+    this.expandAllButton = document.getElementById("expand-all");
+    this.collapsed = new Set(codevisSettings.collapsed || []);
+
+    if (this.tree) {
+      this.init();
+    }
+  }
+
+  init() {
+    for (const item of this.tree.querySelectorAll(".tree-dir")) {
+      if (this.collapsed.has(item.dataset.dir)) {
+        item.classList.add("collapsed");
+      }
+
+      const toggle = item.querySelector(":scope > .tree-toggle");
+      toggle.addEventListener("click", () => this.toggle(item));
+    }
+
+    const showFiles = localStorage.getItem("codevis.treeFiles") == "true";
+    this.filesToggle.checked = showFiles;
+    this.tree.classList.toggle("hide-files", !showFiles);
+    this.filesToggle.addEventListener("change", () => {
+      localStorage.setItem("codevis.treeFiles", this.filesToggle.checked);
+      this.tree.classList.toggle("hide-files", !this.filesToggle.checked);
+    });
+
+    if (!codevisSettings.collapse) {
+      return;
+    }
+
+    this.bindAggregateNodes();
+    this.svg.addEventListener("graphchange", () => this.bindAggregateNodes());
+
+    if (this.collapsed.size > 0) {
+      this.expandAllButton.hidden = false;
+      this.expandAllButton.addEventListener("click", () => {
+        this.collapsed.clear();
+        this.reload();
+      });
+    }
+  }
+
+  bindAggregateNodes() {
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      if (!isAggregateNode(graphNode)) {
+        continue;
+      }
+
+      graphNode.addEventListener("dblclick", (e) => {
+        e.preventDefault();
+        this.collapsed.delete(this.aggregateDir(graphNode));
+        this.reload();
+      });
+    }
+  }
+
+  toggle(item) {
+    const collapsing = !item.classList.contains("collapsed");
+    item.classList.toggle("collapsed", collapsing);
+
+    // Directories without go files have no packages to collapse.
+    if (!codevisSettings.collapse || item.classList.contains("tree-files-only")) {
+      return;
+    }
+
+    if (collapsing) {
+      this.collapsed.add(item.dataset.dir);
+    } else {
+      this.collapsed.delete(item.dataset.dir);
+    }
+    this.reload();
+  }
+
+  // Directory of aggregate node relative to the module root, '.' for the whole module.
+  aggregateDir(graphNode) {
+    const pkgPath = nodeTitle(graphNode).slice(0, -"/...".length);
+    if (pkgPath == codevisSettings.modulePath) {
+      return ".";
+    }
+    return pkgPath.slice(codevisSettings.modulePath.length + 1);
+  }
+
+  reload() {
+    const url = new URL(window.location.href);
+    url.searchParams.delete("collapse");
+    for (const dir of this.collapsed) {
+      url.searchParams.append("collapse", dir);
+    }
+    // Empty parameter expands directories collapsed by default.
+    if (this.collapsed.size == 0) {
+      url.searchParams.set("collapse", "");
+    }
+    window.location.href = url.toString();
+  }
+}
+
+// ModuleLayers shows the standard library and external modules on the graph,
+// one node per module, the page is reloaded with 'layer' query then.
+// Choosing a module or double-clicking its node lists packages importing it.
+class ModuleLayers {
+  constructor(element, svg, sidePanel, viewController, marker, options = {}) {
+    this.element = element;
+    this.svg = svg;
+    this.sidePanel = sidePanel;
+    this.viewController = viewController;
+    this.marker = marker;
+    this.importersSelect = element.querySelector(".module-importers");
+    this.section = null;
+
+    this.init();
+  }
+
+  init() {
+    this.element.hidden = false;
+
+    const layers = codevisSettings.layers || [];
+    for (const toggle of this.element.querySelectorAll("input[type=checkbox]")) {
+      toggle.checked = layers.includes(toggle.value);
+      toggle.addEventListener("change", () => this.reload());
+    }
+
+    for (const module of codevisSettings.modules || []) {
+      const option = document.createElement("option");
+      option.value = module.path;
+      option.textContent = moduleID(module);
+      this.importersSelect.appendChild(option);
+    }
+    this.importersSelect.addEventListener("change", () => {
+      this.showImporters(this.importersSelect.value);
+    });
+
+    this.bindNodes();
+    this.svg.addEventListener("graphchange", () => this.bindNodes());
+  }
+
+  bindNodes() {
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      if (!isLayerNode(graphNode)) {
+        continue;
+      }
+
+      graphNode.addEventListener("dblclick", (e) => {
+        e.preventDefault();
+        const [path] = nodeTitle(graphNode).split("@");
+        this.importersSelect.value = path;
+        this.showImporters(path);
+      });
+    }
+  }
+
+  async showImporters(path) {
+    this.section?.remove();
+    this.section = null;
+    if (!path) {
+      return;
+    }
+
+    const response = await fetch(
+      `/api/modules/importers?${new URLSearchParams({ path })}`,
+    );
+    if (!response.ok) {
+      return;
+    }
+    const importers = await response.json();
+
+    const pkgPaths = Object.keys(importers).sort();
+    const items = pkgPaths.map((pkgPath) => ({
+      text: shortPackagePath(pkgPath),
+      title: importers[pkgPath].join("\n"),
+      onClick: () => {
+        const graphNode = graphNodeByTitle(pkgPath);
+        if (graphNode) {
+          this.viewController.zoomToElement(graphNode);
+          flashGraphNode(graphNode);
+        }
+      },
+    }));
+
+    const name = path == "std" ? "the standard library" : path;
+    this.section = this.sidePanel.addSection(
+      `Importers of ${name} (${items.length})`,
+      items,
+    );
+    this.marker.markByTitles(pkgPaths);
+  }
+
+  reload() {
+    const url = new URL(window.location.href);
+    url.searchParams.delete("layer");
+    for (const toggle of this.element.querySelectorAll("input:checked")) {
+      url.searchParams.append("layer", toggle.value);
+    }
+    window.location.href = url.toString();
+  }
+}
+
+function moduleID(module) {
+  return module.version ? `${module.path}@${module.version}` : module.path;
+}
+
+// GraphExpression re-renders the dependency graph for goda package expression
+// submitted in the form, e.g. './...:-test' or 'reach(./cmd/app:all, ./internal/...)',
+// and swaps the image in place.
+class GraphExpression {
+  constructor(form, svg, options = {}) {
+    this.form = form;
+    this.input = form.querySelector("input");
+    this.error = form.querySelector(".graph-expr-error");
+    this.svg = svg;
+    // Expression of the shown graph, the served page one initially.
+    this.served = codevisSettings.expr || "";
+    this.expr = this.served;
+
+    this.init();
+  }
+
+  init() {
+    this.form.hidden = false;
+    this.input.value = this.expr;
+
+    this.form.addEventListener("submit", (e) => {
+      e.preventDefault();
+      this.apply(this.input.value.trim());
+    });
+    // Zoom keys shouldn't zoom while typing.
+    this.input.addEventListener("keydown", (e) => e.stopPropagation());
+  }
+
+  // Resolves to whether the graph of the expression is shown.
+  async apply(expr) {
+    this.input.value = expr;
+    this.error.textContent = "";
+    this.form.classList.add("loading");
+    try {
+      const response = await fetch(`/graph?${new URLSearchParams({ expr })}`);
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      const doc = new DOMParser().parseFromString(
+        await response.text(),
+        "image/svg+xml",
+      );
+      swapGraph(this.svg, doc.documentElement);
+      this.expr = expr;
+      this.svg.dispatchEvent(new CustomEvent("exprchange"));
+      return true;
+    } catch (err) {
+      this.error.textContent = err.message;
+      return false;
+    } finally {
+      this.form.classList.remove("loading");
+    }
+  }
+}
+
+// ReachabilityQuery dims packages unrelated to a query: packages the chosen one
+// imports directly or transitively, its dependents, or the shortest import path
+// to another package. Alt+click a node to pick the package, found packages are
+// listed in the side panel. Collapsed directories stay lit when any of their
+// packages is found.
+class ReachabilityQuery {
+  constructor(form, svg, sidePanel, viewController, options = {}) {
+    this.form = form;
+    this.kind = form.querySelector(".reach-kind");
+    this.from = form.querySelector(".reach-from");
+    this.to = form.querySelector(".reach-to");
+    this.packages = form.querySelector("datalist");
+    this.clearButton = form.querySelector(".reach-clear");
+    this.error = form.querySelector(".reach-error");
+    this.svg = svg;
+    this.sidePanel = sidePanel;
+    this.viewController = viewController;
+    this.result = null; // {packages: Set, path: []}
+    this.section = null;
+
+    this.init();
+  }
+
+  init() {
+    this.form.hidden = false;
+
+    this.kind.addEventListener("change", () => {
+      this.to.hidden = this.kind.value != "path";
+    });
+    this.form.addEventListener("submit", (e) => {
+      e.preventDefault();
+      this.run();
+    });
+    this.clearButton.addEventListener("click", () => this.clear());
+    // Zoom keys shouldn't zoom while typing.
+    for (const input of [this.from, this.to]) {
+      input.addEventListener("keydown", (e) => e.stopPropagation());
+    }
+
+    this.bindNodes();
+    this.svg.addEventListener("graphchange", () => {
+      this.bindNodes();
+      this.dim();
+    });
+  }
+
+  bindNodes() {
+    this.packages.replaceChildren();
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      if (!isPackageNode(graphNode)) {
+        continue;
+      }
+
+      const option = document.createElement("option");
+      option.value = nodeTitle(graphNode);
+      this.packages.appendChild(option);
+
+      // Capturing listener runs before the marking one and stops it.
+      graphNode.addEventListener(
+        "click",
+        (e) => {
+          if (!e.altKey) {
+            return;
+          }
+          e.preventDefault();
+          e.stopPropagation();
+          this.pick(nodeTitle(graphNode));
+        },
+        true,
+      );
+    }
+  }
+
+  // The first picked package is where the path starts, the second one is where it ends.
+  pick(pkgPath) {
+    const path = this.kind.value == "path";
+    if (path && this.from.value && !this.to.value) {
+      this.to.value = pkgPath;
+    } else {
+      this.from.value = pkgPath;
+      this.to.value = "";
+    }
+
+    if (!path || this.to.value) {
+      this.run();
+    }
+  }
+
+  async run() {
+    const kind = this.kind.value;
+    const from = this.from.value.trim();
+    const to = this.to.value.trim();
+    const params = {
+      reachable: { from },
+      dependents: { of: from },
+      path: { from, to },
+    };
+
+    this.error.textContent = "";
+    try {
+      const response = await fetch(
+        `/api/${kind}?${new URLSearchParams(params[kind])}`,
+      );
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      this.show(kind, from, to, await response.json());
+    } catch (err) {
+      this.error.textContent = err.message;
+    }
+  }
+
+  show(kind, from, to, found) {
+    this.section?.remove();
+
+    const items = found.map((pkgPath, i) => ({
+      text: (kind == "path" && i > 0 ? "→ " : "") + shortPackagePath(pkgPath),
+      title: pkgPath,
+      onClick: () => this.zoomTo(pkgPath),
+    }));
+
+    let heading;
+    if (kind == "reachable") {
+      heading = `Imported by ${shortPackagePath(from)} (${found.length})`;
+    } else if (kind == "dependents") {
+      heading = `Dependents of ${shortPackagePath(from)} (${found.length})`;
+    } else if (found.length == 0) {
+      heading = `No import path from ${shortPackagePath(from)} to ${shortPackagePath(to)}`;
+    } else {
+      heading = `Import path (${found.length - 1})`;
+    }
+
+    this.result = {
+      packages: new Set([from, ...found]),
+      path: kind == "path" ? found : null,
+    };
+    this.section = this.sidePanel.addSection(heading, items);
+    this.dim();
+  }
+
+  // Dims nodes and edges outside of the result on the current graph.
+  dim() {
+    if (!this.result) {
+      return;
+    }
+
+    const titles = Array.from(this.svg.querySelectorAll(".node"), nodeTitle);
+    const shown = (pkgPath) => graphTitleOf(titles, pkgPath);
+
+    const lit = new Set(Array.from(this.result.packages, shown));
+    let litEdges = null;
+    if (this.result.path) {
+      litEdges = new Set();
+      for (let i = 1; i < this.result.path.length; i++) {
+        const [importer, imported] = this.result.path.slice(i - 1, i + 1);
+        litEdges.add(`${shown(importer)}->${shown(imported)}`);
+      }
+    }
+
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      graphNode.classList.toggle("dimmed", !lit.has(nodeTitle(graphNode)));
+    }
+
+    for (const edge of this.svg.querySelectorAll(".edge")) {
+      const [from, to] = edgeEnds(edge);
+      const on = litEdges
+        ? litEdges.has(`${from}->${to}`)
+        : lit.has(from) && lit.has(to);
+      edge.classList.toggle("dimmed", !on);
+    }
+  }
+
+  clear() {
+    this.result = null;
+    this.section?.remove();
+    this.section = null;
+    this.error.textContent = "";
+    for (const element of this.svg.querySelectorAll(".dimmed")) {
+      element.classList.remove("dimmed");
+    }
+  }
+
+  zoomTo(pkgPath) {
+    const titles = Array.from(this.svg.querySelectorAll(".node"), nodeTitle);
+    const graphNode = graphNodeByTitle(graphTitleOf(titles, pkgPath));
+    if (graphNode) {
+      this.viewController.zoomToElement(graphNode);
+      flashGraphNode(graphNode);
+    }
+  }
+}
+
+// ImpactOverlay highlights packages affected by changes since the base revision:
+// packages of changed files, packages depending on them, and dims the rest.
+// Affected packages and ones having tests are listed in the side panel.
+class ImpactOverlay {
+  constructor(form, svg, sidePanel, viewController, options = {}) {
+    this.form = form;
+    this.base = form.querySelector("input");
+    this.clearButton = form.querySelector(".impact-clear");
+    this.error = form.querySelector(".impact-error");
+    this.svg = svg;
+    this.sidePanel = sidePanel;
+    this.viewController = viewController;
+    this.impact = null;
+    this.sections = [];
+
+    this.init();
+  }
+
+  init() {
+    this.form.hidden = false;
+
+    this.form.addEventListener("submit", (e) => {
+      e.preventDefault();
+      this.run(this.base.value.trim());
+    });
+    this.clearButton.addEventListener("click", () => this.clear());
+    // Zoom keys shouldn't zoom while typing.
+    this.base.addEventListener("keydown", (e) => e.stopPropagation());
+
+    this.svg.addEventListener("graphchange", () => this.highlight());
+  }
+
+  async run(base) {
+    this.error.textContent = "";
+    try {
+      const response = await fetch(
+        `/api/impact?${new URLSearchParams({ base })}`,
+      );
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      this.show(await response.json());
+    } catch (err) {
+      this.error.textContent = err.message;
+    }
+  }
+
+  show(impact) {
+    this.clear();
+    this.impact = impact;
+
+    const packageItem = (pkgPath) => ({
+      text: shortPackagePath(pkgPath),
+      title: pkgPath,
+      onClick: () => this.zoomTo(pkgPath),
+    });
+    const lists = [
+      ["Changed packages", impact.changed.map(packageItem)],
+      ["Dependent packages", impact.dependents.map(packageItem)],
+      ["Packages to test", impact.tests.map(packageItem)],
+      [
+        "Files outside packages",
+        impact.unmapped.map((file) => ({ text: file })),
+      ],
+    ];
+    for (const [heading, items] of lists) {
+      this.sections.push(
+        this.sidePanel.addSection(`${heading} (${items.length})`, items),
+      );
+    }
+
+    this.highlight();
+  }
+
+  highlight() {
+    if (!this.impact) {
+      return;
+    }
+
+    const titles = Array.from(this.svg.querySelectorAll(".node"), nodeTitle);
+    const changed = new Set(
+      this.impact.changed.map((pkgPath) => graphTitleOf(titles, pkgPath)),
+    );
+    const impacted = new Set(changed);
+    for (const pkgPath of this.impact.dependents) {
+      impacted.add(graphTitleOf(titles, pkgPath));
+    }
+
+    for (const graphNode of this.svg.querySelectorAll(".node")) {
+      const title = nodeTitle(graphNode);
+      graphNode.classList.toggle("impact-changed", changed.has(title));
+      graphNode.classList.toggle(
+        "impact-dependent",
+        impacted.has(title) && !changed.has(title),
+      );
+      graphNode.classList.toggle("impact-dimmed", !impacted.has(title));
+    }
+
+    for (const edge of this.svg.querySelectorAll(".edge")) {
+      const [from, to] = edgeEnds(edge);
+      edge.classList.toggle(
+        "impact-dimmed",
+        !(impacted.has(from) && impacted.has(to)),
+      );
+    }
+  }
+
+  clear() {
+    this.impact = null;
+    for (const section of this.sections) {
+      section.remove();
+    }
+    this.sections = [];
+    this.error.textContent = "";
+
+    const classes = ["impact-changed", "impact-dependent", "impact-dimmed"];
+    for (const element of this.svg.querySelectorAll(".node, .edge")) {
+      element.classList.remove(...classes);
+    }
+  }
+
+  zoomTo(pkgPath) {
+    const titles = Array.from(this.svg.querySelectorAll(".node"), nodeTitle);
+    const graphNode = graphNodeByTitle(graphTitleOf(titles, pkgPath));
+    if (graphNode) {
+      this.viewController.zoomToElement(graphNode);
+      flashGraphNode(graphNode);
+    }
+  }
+}
+
+// Title of the graph node showing the package, it may be merged into a collapsed directory.
+function graphTitleOf(titles, pkgPath) {
+  for (const title of titles) {
+    if (title == pkgPath) {
+      return title;
+    }
+  }
+  for (const title of titles) {
+    const dir = title.endsWith("/...") ? title.slice(0, -"/...".length) : null;
+    if (dir && (pkgPath == dir || pkgPath.startsWith(dir + "/"))) {
+      return title;
+    }
+  }
+  return pkgPath;
+}
+
+// Edge titles are like 'example.com/app:e->example.com/app/worker:w', ports are optional.
+function edgeEnds(edge) {
+  return edge
+    .getElementsByTagName("title")[0]
+    .textContent.split("->")
+    .map((end) => end.replace(/:[a-z]+$/, ""));
+}
+
+// ViewState keeps the view in the URL hash, so reloading the page or opening a link
+// to it shows the same view: '#expr=<expression>&view=x,y,width,height&mark=<package>&select=<tree entry>'.
+// The expression is kept only when it isn't the served page one.
+class ViewState {
+  constructor(svg, viewController, marker, options = {}) {
+    this.svg = svg;
+    this.viewController = viewController;
+    this.marker = marker;
+    this.graphExpression = options.graphExpression;
+    this.copyButton = document.getElementById("copy-link");
+    this.selected = null;
+    this.saveTimer = null;
+
+    this.init();
+  }
+
+  init() {
+    this.restore();
+    window.addEventListener("hashchange", () => this.restore());
+
+    this.svg.addEventListener("viewboxchange", () => this.scheduleSave());
+    this.svg.addEventListener("markchange", () => this.scheduleSave());
+    this.svg.addEventListener("exprchange", () => this.scheduleSave());
+    for (const entry of document.getElementsByClassName("tree-entry")) {
+      entry.addEventListener("click", () => {
+        this.select(entry);
+        this.scheduleSave();
+      });
+    }
+
+    this.copyButton.addEventListener("click", () => {
+      this.save();
+      navigator.clipboard.writeText(window.location.href);
+    });
+  }
+
+  // Zoom animates the view box, the hash is written once it settles.
+  scheduleSave() {
+    clearTimeout(this.saveTimer);
+    this.saveTimer = setTimeout(() => this.save(), 300);
+  }
+
+  save() {
+    clearTimeout(this.saveTimer);
+
+    const params = new URLSearchParams();
+    const expression = this.graphExpression;
+    if (expression && expression.expr != expression.served) {
+      params.set("expr", expression.expr);
+    }
+    const { x, y, width, height } = this.viewController.currentViewBox;
+    const view = [x, y, width, height].map((n) => Math.round(n * 100) / 100);
+    params.set("view", view.join(","));
+    for (const title of this.marker.markedTitles()) {
+      params.append("mark", title);
+    }
+    if (this.selected) {
+      params.set("select", this.selected.id);
+    }
+
+    // Replacing doesn't fill the history with every pan step.
+    history.replaceState(null, "", `#${params}`);
+  }
+
+  async restore() {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+
+    // The view and marks are of the expression graph, so it's shown first.
+    const expression = this.graphExpression;
+    if (expression) {
+      const expr = params.has("expr") ? params.get("expr") : expression.served;
+      if (expr != expression.expr) {
+        await expression.apply(expr);
+      }
+    }
+
+    const view = params.get("view")?.split(",").map(Number);
+    if (view?.length == 4 && view.every(Number.isFinite)) {
+      const [x, y, width, height] = view;
+      this.viewController.setViewBox({ x, y, width, height });
+    }
+
+    this.marker.clearMarks();
+    this.marker.markByTitles(params.getAll("mark"));
+
+    const entry = params.has("select")
+      ? document.getElementById(params.get("select"))
+      : null;
+    this.select(entry?.classList.contains("tree-entry") ? entry : null);
+    this.selected?.scrollIntoView({ block: "center" });
+  }
+
+  select(entry) {
+    this.selected?.classList.remove("selected");
+    this.selected = entry;
+    this.selected?.classList.add("selected");
+  }
+}
+
+// Bookmarks are named views of the page kept by the server in a local file.
+class Bookmarks {
+  constructor(element, viewState, options = {}) {
+    this.element = element;
+    this.select = element.querySelector("select");
+    this.saveButton = element.querySelector(".bookmark-save");
+    this.deleteButton = element.querySelector(".bookmark-delete");
+    this.error = element.querySelector(".bookmarks-error");
+    this.viewState = viewState;
+    this.bookmarks = [];
+
+    this.init();
+  }
+
+  init() {
+    this.element.hidden = false;
+
+    this.select.addEventListener("change", () => this.open(this.select.value));
+    this.saveButton.addEventListener("click", () => this.save());
+    this.deleteButton.addEventListener("click", () => this.remove());
+
+    this.load();
+  }
+
+  async load() {
+    const response = await fetch("/api/bookmarks");
+    if (!response.ok) {
+      this.error.textContent = await response.text();
+      return;
+    }
+    this.bookmarks = await response.json();
+
+    const selected = this.select.value;
+    this.select.replaceChildren(this.select.options[0]);
+    for (const bookmark of this.bookmarks) {
+      const option = document.createElement("option");
+      option.value = bookmark.name;
+      option.textContent = bookmark.name;
+      option.title = bookmark.url;
+      this.select.appendChild(option);
+    }
+    this.select.value = selected;
+  }
+
+  open(name) {
+    const bookmark = this.bookmarks.find((bookmark) => bookmark.name == name);
+    if (bookmark) {
+      // Only the hash changes for views of the same page, it's restored on 'hashchange'.
+      window.location.href = bookmark.url;
+    }
+  }
+
+  async save() {
+    const name = prompt("Bookmark name", this.select.value)?.trim();
+    if (!name) {
+      return;
+    }
+
+    this.viewState.save();
+    const { pathname, search, hash } = window.location;
+    const saved = await this.request("PUT", "", {
+      name,
+      url: pathname + search + hash,
+    });
+    if (saved) {
+      this.select.value = "";
+      await this.load();
+      this.select.value = name;
+    }
+  }
+
+  async remove() {
+    const name = this.select.value;
+    if (!name || !confirm(`Delete bookmark '${name}'?`)) {
+      return;
+    }
+
+    const deleted = await this.request(
+      "DELETE",
+      `?${new URLSearchParams({ name })}`,
+    );
+    if (deleted) {
+      this.select.value = "";
+      await this.load();
+    }
+  }
+
+  async request(method, query, body) {
+    this.error.textContent = "";
+    const response = await fetch(`/api/bookmarks${query}`, {
+      method,
+      body: body && JSON.stringify(body),
+    });
+    if (!response.ok) {
+      this.error.textContent = await response.text();
+    }
+    return response.ok;
+  }
+}
+
+// Replaces graph image keeping the element, so everything bound to it stays.
+// Node bindings are renewed on 'graphchange' event.
+function swapGraph(svg, source) {
+  svg.dispatchEvent(new CustomEvent("beforegraphchange"));
+
+  for (const name of ["viewBox", "width", "height"]) {
+    svg.setAttribute(name, source.getAttribute(name));
+  }
+  svg.replaceChildren(
+    ...Array.from(source.childNodes, (node) => document.importNode(node, true)),
+  );
+
+  svg.dispatchEvent(new CustomEvent("graphchange"));
+}
+
+// Tree entries match graph nodes of packages and aggregate nodes of collapsed directories.
+function treeEntryGraphNode(svg, entryID) {
+  for (const graphNode of svg.getElementsByClassName("node")) {
+    const title = nodeTitle(graphNode);
+    if (title.endsWith(entryID) || title.endsWith(entryID + "/...")) {
+      return graphNode;
+    }
+  }
+  return null;
+}
+
+// Graph node titles are package paths.
+function graphNodeByTitle(title) {
+  const graphNodes = document.getElementsByClassName("node");
+  for (var i = 0; i < graphNodes.length; i++) {
+    if (nodeTitle(graphNodes[i]) == title) {
+      return graphNodes[i];
+    }
+  }
+  return null;
+}
+
+// Highlights graph node for a while, e.g. after zooming to it.
+// Fills of flashing polygons before the flash, repeated flashes keep the first one.
+const flashedFills = new WeakMap();
+
+// Highlights the node for a while and restores its fill, e.g. a metric or a layer color.
+function flashGraphNode(graphNode) {
+  const polygon = graphNode.getElementsByTagName("polygon")[0];
+  const flashFill = "#FACDEE";
+  if (!flashedFills.has(polygon)) {
+    flashedFills.set(polygon, polygon.getAttribute("fill"));
+  }
+  polygon.setAttribute("fill", flashFill);
+
+  clearTimeout(polygon.flashTimeout);
+  polygon.flashTimeout = setTimeout(() => {
+    const previousFill = flashedFills.get(polygon);
+    flashedFills.delete(polygon);
+    // The fill could be recolored during the flash, e.g. by a metric.
+    if (polygon.getAttribute("fill") != flashFill) {
+      return;
+    }
+    if (previousFill === null) {
+      polygon.removeAttribute("fill");
+    } else {
+      polygon.setAttribute("fill", previousFill);
+    }
+  }, 3000);
+}
+
+// Fill of the shape, the one before the flash for flashing nodes.
+function unflashedFill(shape) {
+  return flashedFills.has(shape)
+    ? flashedFills.get(shape)
+    : shape.getAttribute("fill");
+}
+
+function isTyping(target) {
+  return (
+    ["INPUT", "TEXTAREA", "SELECT"].includes(target.tagName) ||
+    target.isContentEditable
+  );
+}
+
+function inModule(pkgPath) {
+  const modulePath = codevisSettings.modulePath;
+  return pkgPath == modulePath || pkgPath.startsWith(modulePath + "/");
+}
+
+// Module packages are shown relative to the module root.
+function shortPackagePath(pkgPath) {
+  const modulePath = codevisSettings.modulePath;
+  if (!modulePath || !pkgPath.startsWith(modulePath + "/")) {
+    return pkgPath;
+  }
+  return pkgPath.slice(modulePath.length + 1);
+}
+
+// Build callvis url on the origin the page is served from,
+// so several instances may run on different ports.
+function callvisPageURL(params) {
+  const url = new URL("/callvis", window.location.origin);
+  for (const [key, value] of Object.entries(params)) {
+    url.searchParams.set(key, value);
+  }
+  return url.toString();
+}
+
+// Structure of a package is shown in the panel like call graphs.
+function structureOf(pkgPath) {
+  const url = new URL("/structure", window.location.origin);
+  url.searchParams.set("path", pkgPath);
+  return { pkgPath: pkgPath, url: url.toString(), title: "types and functions" };
+}
+
+// Source files are served only, e.g. '/source?path=main.go'.
+function sourcePageURL(params) {
+  const url = new URL("/source", window.location.origin);
+  for (const [key, value] of Object.entries(params)) {
+    url.searchParams.set(key, value);
+  }
+  return url.toString();
+}
+
+// Exported documents are either files next to the page or inlined content.
+function staticDocumentURL(doc) {
+  if (doc.url) {
+    return doc.url;
+  }
+  const blob = new Blob([doc.content], { type: doc.contentType });
+  return URL.createObjectURL(blob);
+}
+
+// Need to zoom to the same scale for any svg size.
+function calculateZoomFactor(svg) {
+  const svgWidth = svg.getBBox().width;
+  // Found 0.0008 empirically
+  let factor = svgWidth * 0.0008;
+  if (factor < 1) {
+    factor = 1;
+  }
+  return factor;
+}
+
+// Init after DOM loaded
+document.addEventListener("DOMContentLoaded", () => {
+  // Init zoomer
+  const svg = document.getElementById("svg");
+  const container = document.getElementById("svgContainer");
+  const viewController = new SVGViewController(svg, container, {
+    zoomFactor: 1.5,
+    zoomElementFactor: calculateZoomFactor(svg),
+    animationDuration: 300,
+  });
+
+  svg.addEventListener("graphchange", () => {
+    viewController.zoomElementFactor = calculateZoomFactor(svg);
+  });
+
+  new TreeView(document.getElementById("tree-container"), svg);
+
+  const callvisPanel = new CallvisPanel(
+    document.getElementById("callvis-panel"),
+  );
+
+  // Revisions compared by diff aren't checked out anymore.
+  // Call graph is either served or pre-rendered on export.
+  if (codevisSettings.callGraph || codevisSettings.staticCallvis) {
+    const callGraphOptions = codevisSettings.callGraph
+      ? new CallGraphOptions(
+          document.getElementById("callgraph-root"),
+          document.getElementById("callgraph-algo"),
+          codevisSettings.mainPackages,
+          { main: codevisSettings.defaultMain, algo: codevisSettings.defaultAlgo },
+        )
+      : null;
+    new CallvisIncluder(svg, callGraphOptions, callvisPanel);
+  }
+
+  if (codevisSettings.structure) {
+    new StructureDrilldown(svg, callvisPanel);
+  }
+
+  if (codevisSettings.search) {
+    new SearchBox(document.getElementById("search"), viewController);
+  }
+
+  if (codevisSettings.editor) {
+    new EditorOpener(svg, callvisPanel);
+  }
+
+  if (codevisSettings.source) {
+    for (const fileEntry of document.getElementsByClassName("gofile")) {
+      fileEntry.addEventListener("click", () =>
+        window.open(sourcePageURL({ path: fileEntry.id }), "_blank"),
+      );
+    }
+  }
+
+  const marker = new SVGMarker(svg, {});
+
+  const sidePanel = new SidePanel(document.getElementById("side-panel"));
+
+  if (codevisSettings.violations) {
+    new ViolationsPanel(sidePanel, viewController, codevisSettings.violations);
+  }
+
+  if (codevisSettings.metrics) {
+    new MetricsOverlay(svg, codevisSettings.metrics);
+  }
+
+  if (codevisSettings.diff) {
+    new DiffPanel(sidePanel, viewController, codevisSettings.diff);
+  }
+
+  if (codevisSettings.cycles) {
+    new CyclesPanel(sidePanel, viewController, marker, codevisSettings.cycles);
+  }
+
+  const graphExpression = codevisSettings.expressions
+    ? new GraphExpression(document.getElementById("graph-expr"), svg)
+    : null;
+
+  const viewState = new ViewState(svg, viewController, marker, {
+    graphExpression,
+  });
+
+  if (codevisSettings.bookmarks) {
+    new Bookmarks(document.getElementById("bookmarks"), viewState);
+  }
+
+  if (codevisSettings.liveReload) {
+    new LiveReloader(viewState);
+  }
+
+  if (codevisSettings.collapse) {
+    new ModuleLayers(
+      document.getElementById("module-layers"),
+      svg,
+      sidePanel,
+      viewController,
+      marker,
+    );
+  }
+
+  if (codevisSettings.reachability) {
+    new ReachabilityQuery(
+      document.getElementById("reach-query"),
+      svg,
+      sidePanel,
+      viewController,
+    );
+  }
+
+  if (codevisSettings.impact) {
+    new ImpactOverlay(
+      document.getElementById("impact"),
+      svg,
+      sidePanel,
+      viewController,
+    );
+  }
+
+  // Set element tree click action - zoom and highlight
+  for (const anchor of document.getElementsByClassName("tree-entry")) {
+    anchor.addEventListener("click", () => {
+      const graphNode = treeEntryGraphNode(svg, anchor.id);
+      if (!graphNode) {
+        return;
+      }
+      viewController.zoomToElement(graphNode);
+      flashGraphNode(graphNode);
+    });
   }
 });
@@ -1,13 +1,180 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/alexuserid/go-codevis/internal/backend"
 )
 
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	// Assigned in init, since commands print usage of each other.
+	commands = map[string]command{
+		"serve":  {usage: "host the page (default)", run: serve},
+		"export": {usage: "write the page with call graphs to a directory or a single .html file", run: export},
+		"doctor": {usage: "check graphviz and go toolchain and print diagnostics", run: doctor},
+		"check":  {usage: "check imports against architecture rules, fail on violations", run: check},
+		"diff":   {usage: "write the page with package graph changes between git revisions", run: diff},
+		"impact": {usage: "print packages affected by changed files, e.g. to test only them", run: impact},
+	}
+}
+
 func main() {
-	if err := backend.Run(); err != nil {
-		log.Fatalf("run app failed: %s", err)
+	args := os.Args[1:]
+
+	name := "serve"
+	if len(args) > 0 {
+		if _, ok := commands[args[0]]; ok {
+			name = args[0]
+			args = args[1:]
+		}
+	}
+
+	if err := commands[name].run(args); err != nil {
+		log.Fatalf("%s failed: %s", name, err)
+	}
+}
+
+func serve(args []string) error {
+	cfg := backend.DefaultConfig()
+
+	fs := newFlagSet("serve", "[flags] [dir]")
+	registerCommonFlags(fs, &cfg)
+	fs.StringVar(&cfg.Addr, "addr", cfg.Addr, "address to listen on, e.g. 'localhost:8080' or ':0' for a random port")
+	fs.BoolVar(&cfg.OpenBrowser, "open", cfg.OpenBrowser, "open the page in the default browser")
+	fs.BoolVar(&cfg.Watch, "watch", cfg.Watch, "rebuild the page on source, rules or file tree changes and reload it in the browser")
+	fs.StringVar(&cfg.BookmarksFile, "bookmarks", cfg.BookmarksFile, "file named views of the page are kept in (default '.codevis-bookmarks.json' in the module root)")
+	fs.StringVar(&cfg.Editor, "editor", cfg.Editor, "editor to open files in: 'code', 'goland', 'vscode' or a command or URI template with '{file}' and '{line}' (default $EDITOR)")
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		cfg.Dir = fs.Arg(0)
+	}
+
+	return backend.Run(cfg)
+}
+
+func export(args []string) error {
+	cfg := backend.DefaultConfig()
+
+	fs := newFlagSet("export", "[flags] [dir]")
+	registerCommonFlags(fs, &cfg)
+	output := fs.String("o", "codevis-report", "output directory, or a file when it ends with '.html'")
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		cfg.Dir = fs.Arg(0)
+	}
+
+	return backend.Export(cfg, *output)
+}
+
+func doctor(args []string) error {
+	cfg := backend.DefaultConfig()
+
+	fs := newFlagSet("doctor", "[flags] [dir]")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "root directory of the module to visualize")
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		cfg.Dir = fs.Arg(0)
 	}
+
+	return backend.Doctor(cfg, os.Stdout)
+}
+
+func check(args []string) error {
+	cfg := backend.DefaultConfig()
+
+	fs := newFlagSet("check", "[flags] [dir]")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "root directory of the module to check")
+	registerRulesFlag(fs, &cfg)
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		cfg.Dir = fs.Arg(0)
+	}
+
+	return backend.CheckRules(cfg, os.Stdout)
+}
+
+func diff(args []string) error {
+	cfg := backend.DefaultConfig()
+
+	fs := newFlagSet("diff", "[flags] base [head]\nHead defaults to the working tree with uncommitted changes.")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "root directory of the module to visualize")
+	fs.BoolVar(&cfg.WithHidden, "hidden", cfg.WithHidden, "include hidden files and directories")
+	fs.StringVar(&cfg.Renderer, "renderer", cfg.Renderer, "graph renderer: 'auto', 'dot' (graphviz util) or 'embedded'")
+	fs.StringVar(&cfg.Expr, "expr", cfg.Expr, "goda package expression the dependency graphs are built of, e.g. './...:-test' (default './...')")
+	fs.BoolVar(&cfg.OpenBrowser, "open", cfg.OpenBrowser, "open the page in the default browser")
+	output := fs.String("o", "codevis-diff.html", "output file")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		fs.Usage()
+		return errors.New("expected base and optional head revisions")
+	}
+
+	return backend.Diff(cfg, fs.Arg(0), fs.Arg(1), *output, os.Stdout)
+}
+
+func impact(args []string) error {
+	cfg := backend.DefaultConfig()
+
+	fs := newFlagSet("impact", "[flags] [file...]\nFiles default to ones changed since the fork from base revision, uncommitted and untracked included.")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "root directory of the module")
+	fs.BoolVar(&cfg.WithHidden, "hidden", cfg.WithHidden, "include hidden files and directories")
+	fs.StringVar(&cfg.Expr, "expr", cfg.Expr, "goda package expression the dependency graph is built of (default './...')")
+	base := fs.String("base", "HEAD", "git revision files changed since the fork from")
+	tests := fs.Bool("tests", false, "print only packages having tests")
+	fs.Parse(args)
+
+	return backend.Impact(cfg, *base, fs.Args(), *tests, os.Stdout)
+}
+
+func registerCommonFlags(fs *flag.FlagSet, cfg *backend.Config) {
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "root directory of the module to visualize")
+	fs.BoolVar(&cfg.WithHidden, "hidden", cfg.WithHidden, "include hidden files and directories")
+	fs.StringVar(&cfg.Renderer, "renderer", cfg.Renderer, "graph renderer: 'auto', 'dot' (graphviz util) or 'embedded'")
+	registerRulesFlag(fs, cfg)
+	fs.StringVar(&cfg.CallGraphAlgo, "algo", cfg.CallGraphAlgo, "default call graph algorithm: 'static', 'cha', 'rta', 'vta' or 'pointer'")
+	fs.IntVar(&cfg.CycleDepth, "cycle-depth", cfg.CycleDepth, "number of leading directories packages are grouped by to find import cycles, 0 to not group")
+	fs.StringVar(&cfg.Expr, "expr", cfg.Expr, "goda package expression the dependency graph is built of, e.g. './...:-test' (default './...')")
+	fs.Func("collapse", "comma separated glob patterns of directories collapsed into one node, e.g. 'internal/*,pkg' (repeatable)", func(value string) error {
+		cfg.Collapse = append(cfg.Collapse, strings.Split(value, ",")...)
+		return nil
+	})
+}
+
+func registerRulesFlag(fs *flag.FlagSet, cfg *backend.Config) {
+	fs.StringVar(&cfg.RulesFile, "rules", cfg.RulesFile, "architecture rules file (default '.codevis.yaml' in the module root, if exists)")
+}
+
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s %s %s\n", os.Args[0], name, usage)
+		fs.PrintDefaults()
+
+		fmt.Fprintln(out, "\nCommands:")
+		for _, commandName := range slices.Sorted(maps.Keys(commands)) {
+			fmt.Fprintf(out, "  %-8s %s\n", commandName, commands[commandName].usage)
+		}
+	}
+
+	return fs
 }